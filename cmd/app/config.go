@@ -0,0 +1,326 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ivanglie/usdrub-bot/internal/cash"
+	"github.com/ivanglie/usdrub-bot/internal/coins"
+	"github.com/ivanglie/usdrub-bot/internal/config"
+	"github.com/ivanglie/usdrub-bot/internal/crypto"
+	"github.com/ivanglie/usdrub-bot/internal/exchange"
+	"github.com/ivanglie/usdrub-bot/internal/fixing"
+	"github.com/ivanglie/usdrub-bot/internal/forecast"
+	"github.com/ivanglie/usdrub-bot/internal/futures"
+	"github.com/ivanglie/usdrub-bot/internal/history"
+	"github.com/ivanglie/usdrub-bot/internal/premium"
+	"github.com/ivanglie/usdrub-bot/internal/scheduler"
+	"github.com/ivanglie/usdrub-bot/pkg/bankiru-go"
+)
+
+// schedule of rate updates, which can be restarted with a new config.
+type schedule struct {
+	sync.Mutex
+	cancel context.CancelFunc
+	done   <-chan struct{}
+}
+
+// Start jobs of cfg by calendar cal, stopping the previous ones.
+func (s *schedule) Start(ctx context.Context, cfg *config.Config, cal *scheduler.Calendar) error {
+	loc, err := time.LoadLocation(cfg.Location)
+	if err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	s.stop()
+
+	ctx, cancel := context.WithCancel(ctx)
+	done, err := scheduler.Start(ctx, loc, cal, jobs(cfg)...)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	s.cancel, s.done = cancel, done
+
+	return nil
+}
+
+// Stop jobs and wait for running ones.
+func (s *schedule) Stop() {
+	s.Lock()
+	defer s.Unlock()
+
+	s.stop()
+}
+
+func (s *schedule) stop() {
+	if s.cancel == nil {
+		return
+	}
+
+	s.cancel()
+	<-s.done
+	s.cancel, s.done = nil, nil
+}
+
+// jobs returns scheduled updates of every enabled provider.
+func jobs(cfg *config.Config) []scheduler.Job {
+	exchangeCmd := func(name string) func(ctx context.Context) {
+		return func(ctx context.Context) { exchange.Get().UpdateValue(ctx, name) }
+	}
+
+	cashCmd := func(ctx context.Context) {
+		for _, cur := range cfg.Providers.Cash.Currencies {
+			cash.For(bankiru.Currency(cur)).Update(ctx)
+		}
+	}
+
+	cryptoCmd := func(ctx context.Context) {
+		for _, d := range cfg.Providers.Crypto.ExchangeDirections() {
+			crypto.For(d).Update(ctx)
+		}
+
+		observePremium(cfg)
+	}
+
+	all := []struct {
+		provider string
+		name     string
+		cmd      func(ctx context.Context)
+	}{
+		{config.Forex, exchange.Forex, exchangeCmd(exchange.Forex)},
+		{config.MOEX, exchange.MOEX, exchangeCmd(exchange.MOEX)},
+		{config.CBRF, exchange.CBRF, exchangeCmd(exchange.CBRF)},
+		{config.Cash, "Banki.ru", cashCmd},
+		{config.Crypto, "BestChange", cryptoCmd},
+		{config.Coins, "CoinGate", coins.Get().Update},
+		{config.Futures, "FORTS", futures.Get().Update},
+	}
+
+	jj := []scheduler.Job{}
+	for _, v := range all {
+		p := cfg.Providers.Get(v.provider)
+		if !p.Enabled {
+			continue
+		}
+
+		j := scheduler.Job{Name: v.name, Spec: p.Schedule, Jitter: cfg.Jitter, Cmd: v.cmd}
+		if p.Days == config.TradingDays {
+			j.Days = scheduler.TradingDays
+		}
+
+		jj = append(jj, j)
+	}
+
+	// Fixings are published a few times a day
+	if cfg.Providers.MOEX.Enabled {
+		jj = append(jj, scheduler.Job{Name: "MOEX fixings", Spec: "*/15 10-20 * * *", Days: scheduler.TradingDays, Jitter: cfg.Jitter,
+			Cmd: fixing.Get().Update})
+	}
+
+	// Official rates are set by trading of the day, the estimate skips days off itself
+	if cfg.Providers.CBRF.Enabled {
+		jj = append(jj, scheduler.Job{Name: "CBRF estimate", Spec: "*/10 10-17 * * *", Jitter: cfg.Jitter, Cmd: forecast.Get().Update})
+	}
+
+	if len(cfg.Storage) > 0 {
+		jj = append(jj, scheduler.Job{Name: "History", Spec: "*/10 * * * *", Cmd: func(ctx context.Context) { flushHistory() }})
+	}
+
+	return jj
+}
+
+// observePremium of USDT rates of every configured direction.
+func observePremium(cfg *config.Config) {
+	for _, d := range cfg.Providers.Crypto.ExchangeDirections() {
+		premium.Get().Observe(d, premium.Of(d))
+	}
+}
+
+// flushHistory to the storage.
+func flushHistory() {
+	if err := history.Get().Flush(); err != nil {
+		log.Errorf("History is not saved: %v", err)
+	}
+}
+
+// loadConfig returns config built from command line options, overridden by config file if it's set.
+func loadConfig() (*config.Config, error) {
+	base := config.Default()
+	base.Listen = opts.Listen
+	base.Jitter = opts.Schedule.Jitter
+	base.Calendar = opts.Schedule.Calendar
+
+	specs := map[string]string{
+		config.Forex:  opts.Schedule.Forex,
+		config.MOEX:   opts.Schedule.MOEX,
+		config.CBRF:   opts.Schedule.CBRF,
+		config.Cash:   opts.Schedule.Cash,
+		config.Crypto: opts.Schedule.Crypto,
+	}
+
+	for _, name := range config.Names {
+		p := base.Providers.Get(name)
+
+		switch {
+		case len(specs[name]) > 0:
+			p.Schedule = specs[name]
+		case len(opts.CronSpec) > 0:
+			p.Schedule = opts.CronSpec
+		}
+
+		p.Days = config.EveryDay
+		for _, v := range opts.Schedule.TradingDays {
+			if v == name {
+				p.Days = config.TradingDays
+			}
+		}
+	}
+
+	if len(opts.Config) == 0 {
+		return base, base.Validate()
+	}
+
+	return config.Load(opts.Config, base)
+}
+
+// apply cfg to rates and make it current. It returns calendar of cfg.
+func apply(cfg *config.Config) (*scheduler.Calendar, error) {
+	for _, v := range []struct{ provider, name string }{
+		{config.Forex, exchange.Forex},
+		{config.MOEX, exchange.MOEX},
+		{config.CBRF, exchange.CBRF},
+	} {
+		p := cfg.Providers.Get(v.provider)
+		if err := exchange.Get().Configure(v.name, p.Enabled, p.Pair); err != nil {
+			return nil, err
+		}
+	}
+
+	// Calendar is built anew, so days of the previous calendar file aren't kept
+	cal, err := scheduler.LoadCalendar(cfg.Calendar)
+	if err != nil {
+		return nil, err
+	}
+
+	maxAge, err := freshness(cfg, cal)
+	if err != nil {
+		return nil, err
+	}
+
+	// Prices of Period ago are expected within the interval of updates
+	interval, err := scheduler.Interval(cfg.Providers.Coins.Schedule, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cur := range cfg.Providers.Cash.Currencies {
+		cash.For(bankiru.Currency(cur)).Configure(bankiru.City(cfg.Providers.Cash.City), cfg.Providers.Cash.Limit)
+		cash.For(bankiru.Currency(cur)).SetMaxAge(maxAge)
+		cash.For(bankiru.Currency(cur)).SetStatistic(cash.Statistic(cfg.Providers.Cash.Statistic))
+	}
+
+	crypto.Get().SetSuffix(cfg.Templates.CryptoSuffix)
+	premium.Get().SetThreshold(cfg.Providers.Crypto.PremiumAlert)
+	coins.Get().Configure(cfg.Providers.Coins.Currencies)
+	coins.Get().SetInterval(interval + cfg.Jitter)
+	fixing.Get().Configure(cfg.Providers.MOEX.Pair)
+	forecast.Get().Configure(cfg.Providers.CBRF.Pair)
+	forecast.Get().SetWorkday(cal.IsWorkday)
+	futures.Get().Configure(cfg.Providers.Futures.Assets)
+
+	config.Set(cfg)
+
+	return cal, nil
+}
+
+// freshness returns freshness window of cash quotes, which is tighter on workdays of calendar cal if it's set.
+func freshness(cfg *config.Config, cal *scheduler.Calendar) (func() time.Duration, error) {
+	p := cfg.Providers.Cash
+	if p.WorkdayMaxAge <= 0 {
+		return func() time.Duration { return p.MaxAge }, nil
+	}
+
+	loc, err := time.LoadLocation(cfg.Location)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() time.Duration {
+		if cal.IsWorkday(time.Now().In(loc)) {
+			return p.WorkdayMaxAge
+		}
+
+		return p.MaxAge
+	}, nil
+}
+
+// reloadOnSIGHUP reloads config file and restarts schedule on SIGHUP until ctx is done.
+// The returned channel is closed when it's stopped.
+func reloadOnSIGHUP(ctx context.Context, sched *schedule) <-chan struct{} {
+	done := make(chan struct{})
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer close(done)
+		defer signal.Stop(hup)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				reload(ctx, sched)
+			}
+		}
+	}()
+
+	return done
+}
+
+// reload config, keeping the current one if the new one is invalid.
+func reload(ctx context.Context, sched *schedule) {
+	if len(opts.Config) == 0 {
+		log.Warn("SIGHUP received, but no config file is set")
+		return
+	}
+
+	cur := config.Get()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Errorf("Config is not reloaded: %v", err)
+		return
+	}
+
+	if cfg.Listen != cur.Listen || cfg.Storage != cur.Storage {
+		log.Warn("Changes of listen and storage require restart")
+		cfg.Listen, cfg.Storage = cur.Listen, cur.Storage
+	}
+
+	cal, err := apply(cfg)
+	if err != nil {
+		log.Errorf("Config is not applied: %v", err)
+		_, _ = apply(cur)
+		return
+	}
+
+	if err := sched.Start(ctx, cfg, cal); err != nil {
+		log.Errorf("Schedule is not restarted: %v", err)
+		return
+	}
+
+	log.Infof("Config %s is reloaded", opts.Config)
+
+	updateRates(ctx)
+}
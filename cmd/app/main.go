@@ -1,18 +1,34 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"html"
+	"net/http"
 	"os"
+	"os/signal"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/ivanglie/usdrub-bot/internal/cash"
+	"github.com/ivanglie/usdrub-bot/internal/coins"
+	"github.com/ivanglie/usdrub-bot/internal/config"
 	"github.com/ivanglie/usdrub-bot/internal/crypto"
+	"github.com/ivanglie/usdrub-bot/internal/drift"
 	"github.com/ivanglie/usdrub-bot/internal/exchange"
+	"github.com/ivanglie/usdrub-bot/internal/fixing"
+	"github.com/ivanglie/usdrub-bot/internal/forecast"
+	"github.com/ivanglie/usdrub-bot/internal/futures"
+	"github.com/ivanglie/usdrub-bot/internal/health"
+	"github.com/ivanglie/usdrub-bot/internal/history"
 	"github.com/ivanglie/usdrub-bot/internal/logger"
-	"github.com/ivanglie/usdrub-bot/internal/scheduler"
+	"github.com/ivanglie/usdrub-bot/internal/metrics"
+	"github.com/ivanglie/usdrub-bot/internal/premium"
+	"github.com/ivanglie/usdrub-bot/internal/receiver"
+	"github.com/ivanglie/usdrub-bot/internal/route"
 	"github.com/ivanglie/usdrub-bot/pkg/bankiru-go"
 	"github.com/ivanglie/usdrub-bot/pkg/bestchange-go"
 	"github.com/ivanglie/usdrub-bot/pkg/cbr-go"
@@ -23,17 +39,37 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-const (
-	helpCmd = "Just use /forex, /moex, /cbrf, /cash, /crypto and /dashboard command."
-)
-
 var (
 	log *logrus.Logger
 
 	opts struct {
 		Dbg      bool   `long:"dbg" env:"DEBUG" description:"Debug mode"`
 		BotToken string `long:"bottoken" env:"BOT_TOKEN" description:"Telegram API Token"`
-		CronSpec string `long:"cronspec" env:"CRON_SPEC" description:"Cron spec"`
+		Config   string `long:"config" env:"CONFIG" description:"YAML configuration file, reloaded on SIGHUP"`
+		CronSpec string `long:"cronspec" env:"CRON_SPEC" description:"Cron spec of every source without its own spec"`
+		Listen   string `long:"listen" env:"LISTEN" default:":8080" description:"HTTP listen address"`
+
+		ReadyThreshold time.Duration `long:"readythreshold" env:"READY_THRESHOLD" default:"12h" description:"Maximum age of exchange rates for readiness"`
+
+		Mode        string `long:"mode" env:"MODE" choice:"polling" choice:"webhook" default:"polling" description:"Update receiving mode"`
+		APIEndpoint string `long:"apiendpoint" env:"API_ENDPOINT" description:"Telegram Bot API endpoint, e.g. https://api.telegram.org/bot%s/%s"`
+		Webhook     struct {
+			URL      string        `long:"url" env:"URL" description:"Public HTTPS URL of the webhook"`
+			Secret   string        `long:"secret" env:"SECRET" description:"Secret token of the webhook"`
+			Interval time.Duration `long:"interval" env:"INTERVAL" default:"1m" description:"Webhook registration check interval"`
+		} `group:"webhook" namespace:"webhook" env-namespace:"WEBHOOK"`
+
+		Schedule struct {
+			Forex  string        `long:"forex" env:"FOREX" description:"Cron spec of Forex rate updates (default: * * * * *)"`
+			MOEX   string        `long:"moex" env:"MOEX" description:"Cron spec of MOEX rate updates (default: * 10-23 * * *)"`
+			CBRF   string        `long:"cbrf" env:"CBRF" description:"Cron spec of CBRF rate updates (default: 0 * * * *)"`
+			Cash   string        `long:"cash" env:"CASH" description:"Cron spec of Banki.ru cash rate updates (default: */10 * * * *)"`
+			Crypto string        `long:"crypto" env:"CRYPTO" description:"Cron spec of BestChange rate updates (default: */5 * * * *)"`
+			Jitter time.Duration `long:"jitter" env:"JITTER" default:"10s" description:"Maximum random delay before each update"`
+
+			TradingDays []string `long:"tradingdays" env:"TRADING_DAYS" env-delim:"," default:"moex" default:"futures" choice:"forex" choice:"moex" choice:"cbrf" choice:"cash" choice:"crypto" choice:"coins" choice:"futures" description:"Sources updated on MOEX trading days only"`
+			Calendar    string   `long:"calendar" env:"CALENDAR" description:"Calendar file with extra holidays, working and non-trading days"`
+		} `group:"schedule" namespace:"schedule" env-namespace:"SCHEDULE"`
 	}
 
 	kb = tgbotapi.NewInlineKeyboardMarkup(
@@ -44,6 +80,13 @@ var (
 		),
 	)
 
+	cryptoKb = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Top offers", "Offers"),
+			tgbotapi.NewInlineKeyboardButtonData("Help", "Help"),
+		),
+	)
+
 	version = "unknown"
 )
 
@@ -63,35 +106,44 @@ func main() {
 	coingate.Debug, moex.Debug, cbr.Debug, bankiru.Debug, bestchange.Debug, logger.Debug = opts.Dbg, opts.Dbg, opts.Dbg,
 		opts.Dbg, opts.Dbg, opts.Dbg
 
-	updateRates := func() {
-		t := time.Now()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-		type RateInterface interface {
-			Update()
-		}
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Panic(err)
+	}
 
-		rates := []RateInterface{exchange.Get(), cash.Get(), crypto.Get()}
+	cal, err := apply(cfg)
+	if err != nil {
+		log.Panic(err)
+	}
 
-		wg := sync.WaitGroup{}
-		for _, r := range rates {
-			wg.Add(1)
-			go func(r RateInterface) {
-				defer wg.Done()
-				r.Update()
-			}(r)
+	if len(cfg.Storage) > 0 {
+		if err := history.Get().Open(cfg.Storage); err != nil {
+			log.Errorf("History is not loaded: %v", err)
 		}
-
-		wg.Wait()
-		log.Debugln("Elapsed time:", time.Since(t))
 	}
 
-	updateRates()
+	updateRates(ctx)
+
+	health.Get().SetThreshold(opts.ReadyThreshold)
+	for _, name := range []string{exchange.Forex, exchange.MOEX, exchange.CBRF} {
+		health.Get().AddSource(name, exchange.Get().Value(name).Updated)
+	}
 
-	if err := scheduler.StartCmdOnSchedule(updateRates); err != nil {
+	sched := &schedule{}
+	if err := sched.Start(ctx, cfg, cal); err != nil {
 		log.Panic(err)
 	}
 
-	bot, err := tgbotapi.NewBotAPI(opts.BotToken)
+	reloaded := reloadOnSIGHUP(ctx, sched)
+
+	if len(opts.APIEndpoint) == 0 {
+		opts.APIEndpoint = tgbotapi.APIEndpoint
+	}
+
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint(opts.BotToken, opts.APIEndpoint)
 	if err != nil {
 		log.Panic(err)
 	}
@@ -100,51 +152,172 @@ func main() {
 
 	log.Debugf("Authorized on account %s", bot.Self.UserName)
 
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
+	drift.Get().SetNotifier(func(text string) { notifyAdmins(bot, text) })
+	premium.Get().SetNotifier(func(text string) { notifyAdmins(bot, text) })
 
-	updates := bot.GetUpdatesChan(u)
+	mux := http.NewServeMux()
 
-	for update := range updates {
-		if update.Message != nil {
+	var rcv receiver.Receiver = receiver.NewPoller(bot, 60)
+	if opts.Mode == "webhook" {
+		wh, err := receiver.NewWebhook(bot, opts.Webhook.URL, opts.Webhook.Secret, opts.Webhook.Interval)
+		if err != nil {
+			log.Panic(err)
+		}
 
-			if !update.Message.IsCommand() {
-				continue
-			}
+		// Webhook receiver beats once per check interval
+		if d := 2 * opts.Webhook.Interval; d > health.DefaultHeartbeat {
+			health.Get().SetHeartbeat(d)
+		}
 
-			switch update.Message.Command() {
-			case "forex":
-				forexHandler(bot, update)
-			case "moex":
-				moexHandler(bot, update)
-			case "cbrf":
-				cbrfHandler(bot, update)
-			case "cash":
-				cashHandler(bot, update)
-			case "crypto":
-				cryptoHandler(bot, update)
-			case "help":
-				helpHandler(bot, update)
-			case "start":
-				start(bot, update)
-			case "dashboard":
-				dashboardHandler(bot, update)
-			default:
-				log.Warnf("Unknown command %q", update.Message.Command())
-			}
+		mux.Handle(wh.Path(), wh)
+		rcv = wh
+	}
+
+	srv := serveHTTP(cfg.Listen, mux)
+
+	handlers := sync.WaitGroup{}
+	for update := range rcv.Updates(ctx) {
+		handlers.Add(1)
+		go func(update tgbotapi.Update) {
+			defer handlers.Done()
+			handleUpdate(bot, update)
+		}(update)
+	}
+
+	log.Info("Shutting down")
+
+	handlers.Wait()
+	<-reloaded
+	sched.Stop()
+	flushHistory()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Errorf("HTTP server shutdown error: %v", err)
+	}
+
+	log.Info("Stopped")
+}
+
+// updateRates of every enabled source.
+func updateRates(ctx context.Context) {
+	t := time.Now()
+
+	type RateInterface interface {
+		Update(ctx context.Context)
+	}
+
+	cfg := config.Get()
+
+	rates := []RateInterface{exchange.Get()}
+	if cfg.Providers.MOEX.Enabled {
+		rates = append(rates, fixing.Get())
+	}
+
+	if cfg.Providers.CBRF.Enabled {
+		rates = append(rates, forecast.Get())
+	}
+
+	if cfg.Providers.Cash.Enabled {
+		for _, cur := range cfg.Providers.Cash.Currencies {
+			rates = append(rates, cash.For(bankiru.Currency(cur)))
 		}
+	}
 
-		if update.CallbackQuery != nil {
-			switch update.CallbackQuery.Data {
-			case "Buy":
-				onBuy(bot, update.CallbackQuery)
-			case "Sell":
-				onSell(bot, update.CallbackQuery)
-			case "Help":
-				onHelp(bot, update.CallbackQuery)
-			default:
-				log.Warnf("Unknown callback %q", update.CallbackQuery.Data)
-			}
+	if cfg.Providers.Crypto.Enabled {
+		for _, d := range cfg.Providers.Crypto.ExchangeDirections() {
+			rates = append(rates, crypto.For(d))
+		}
+	}
+
+	if cfg.Providers.Coins.Enabled {
+		rates = append(rates, coins.Get())
+	}
+
+	if cfg.Providers.Futures.Enabled {
+		rates = append(rates, futures.Get())
+	}
+
+	wg := sync.WaitGroup{}
+	for _, r := range rates {
+		wg.Add(1)
+		go func(r RateInterface) {
+			defer wg.Done()
+			r.Update(ctx)
+		}(r)
+	}
+
+	wg.Wait()
+
+	if cfg.Providers.Crypto.Enabled {
+		observePremium(cfg)
+	}
+
+	log.Debugln("Elapsed time:", time.Since(t))
+}
+
+// handleUpdate dispatches update to the command or callback handler.
+func handleUpdate(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
+	if update.Message != nil {
+
+		if update.Message.Location != nil {
+			countCommand("location")
+			locationHandler(bot, update)
+			return
+		}
+
+		if !update.Message.IsCommand() {
+			return
+		}
+
+		countCommand(update.Message.Command())
+
+		switch update.Message.Command() {
+		case "forex":
+			forexHandler(bot, update)
+		case "moex":
+			moexHandler(bot, update)
+		case "cbrf":
+			cbrfHandler(bot, update)
+		case "cash":
+			cashHandler(bot, update)
+		case "crypto":
+			cryptoHandler(bot, update)
+		case "coin":
+			coinHandler(bot, update)
+		case "route":
+			routeHandler(bot, update)
+		case "futures":
+			futuresHandler(bot, update)
+		case "help":
+			helpHandler(bot, update)
+		case "start":
+			start(bot, update)
+		case "dashboard":
+			dashboardHandler(bot, update)
+		default:
+			log.Warnf("Unknown command %q", update.Message.Command())
+		}
+	}
+
+	if update.CallbackQuery != nil {
+		// Data is "<name>[:<currency or direction>]"
+		name, arg, _ := strings.Cut(update.CallbackQuery.Data, ":")
+		countCommand(name)
+
+		switch name {
+		case "Buy":
+			onBuy(bot, update.CallbackQuery, bankiru.Currency(arg))
+		case "Sell":
+			onSell(bot, update.CallbackQuery, bankiru.Currency(arg))
+		case "Offers":
+			onOffers(bot, update.CallbackQuery, arg)
+		case "Help":
+			onHelp(bot, update.CallbackQuery)
+		default:
+			log.Warnf("Unknown callback %q", update.CallbackQuery.Data)
 		}
 	}
 }
@@ -152,73 +325,269 @@ func main() {
 func forexHandler(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
 	log.Infof("Forex request from %s", update.Message.From)
 
+	if !enabled(bot, update.Message, config.Forex, "forex") {
+		return
+	}
+
 	msg := tgbotapi.NewMessage(
 		update.Message.Chat.ID,
-		fmt.Sprintln(exchange.Prefix, exchange.Get().Value(exchange.Forex)),
+		fmt.Sprintln(config.Get().Templates.Exchange, exchange.Get().Value(exchange.Forex)),
 	)
 
 	msg.ParseMode = tgbotapi.ModeHTML
 	msg.ReplyToMessageID = getReplyMessageID(update.Message)
 
-	bot.Send(msg)
+	send(bot, msg)
 }
 
 func moexHandler(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
 	log.Infof("Moex request from %s", update.Message.From)
 
-	msg := tgbotapi.NewMessage(
-		update.Message.Chat.ID,
-		fmt.Sprintln(exchange.Prefix, exchange.Get().Value(exchange.MOEX)),
-	)
+	if !enabled(bot, update.Message, config.MOEX, "moex") {
+		return
+	}
+
+	t := fmt.Sprintln(config.Get().Templates.Exchange, exchange.Get().Value(exchange.MOEX))
+	if f := fixing.Get().String(); len(f) > 0 {
+		t += f + "\n"
+	}
+
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, t)
 
 	msg.ParseMode = tgbotapi.ModeHTML
 	msg.ReplyToMessageID = getReplyMessageID(update.Message)
 
-	bot.Send(msg)
+	send(bot, msg)
 }
 
 func cbrfHandler(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
 	log.Infof("Cbrf request from %s", update.Message.From)
 
-	msg := tgbotapi.NewMessage(
-		update.Message.Chat.ID,
-		fmt.Sprintln(exchange.Prefix, exchange.Get().Value(exchange.CBRF)),
-	)
+	if !enabled(bot, update.Message, config.CBRF, "cbrf") {
+		return
+	}
+
+	t := fmt.Sprintln(config.Get().Templates.Exchange, exchange.Get().Value(exchange.CBRF))
+	if f := forecast.Get().String(); len(f) > 0 {
+		t += f + "\n"
+	}
+
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, t)
 
 	msg.ParseMode = tgbotapi.ModeHTML
 	msg.ReplyToMessageID = getReplyMessageID(update.Message)
 
-	bot.Send(msg)
+	send(bot, msg)
 }
 
 func cashHandler(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
 	log.Infof("Cash request from %s", update.Message.From)
 
+	if !enabled(bot, update.Message, config.Cash, "cash") {
+		return
+	}
+
+	cur := bankiru.USD
+	if arg := strings.TrimSpace(update.Message.CommandArguments()); len(arg) > 0 {
+		cur = bankiru.Currency(strings.ToUpper(arg))
+	}
+
+	cfg := config.Get()
+	if !cfg.Providers.Cash.HasCurrency(string(cur)) {
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("Unsupported currency %q, use one of: %s.",
+			cur, strings.Join(cfg.Providers.Cash.Currencies, ", ")))
+		msg.ReplyToMessageID = getReplyMessageID(update.Message)
+		send(bot, msg)
+		return
+	}
+
+	title := cfg.Templates.Cash
+	if cur != bankiru.USD {
+		title = fmt.Sprintf("%s (%s)", title, cur)
+	}
+
 	msg := tgbotapi.NewMessage(
 		update.Message.Chat.ID,
-		fmt.Sprintf("<b>%s</b>\n%s\n%s", cash.Prefix, cash.Get().String(), cash.Suffix),
+		fmt.Sprintf("<b>%s</b>\n%s\n%s", title, cash.For(cur).String(), cfg.Templates.CashSuffix),
 	)
 
 	msg.ParseMode = tgbotapi.ModeHTML
 	msg.ReplyToMessageID = getReplyMessageID(update.Message)
-	msg.ReplyMarkup = &kb
+	msg.ReplyMarkup = keyboard(cur)
 
-	bot.Send(msg)
+	send(bot, msg)
 }
 
 func cryptoHandler(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
 	log.Infof("Crypto request from %s", update.Message.From)
 
-	msg := tgbotapi.NewMessage(
-		update.Message.Chat.ID,
-		fmt.Sprintf("<b>%s</b>\n%s", crypto.Prefix, crypto.Get().String()),
-	)
+	if !enabled(bot, update.Message, config.Crypto, "crypto") {
+		return
+	}
+
+	dd := config.Get().Providers.Crypto.ExchangeDirections()
+	d, err := crypto.Select(dd, strings.Fields(update.Message.CommandArguments()))
+	if err != nil {
+		names := []string{}
+		for _, v := range dd {
+			names = append(names, v.String())
+		}
+
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("No direction matches %q, use e.g. /crypto sell spb. Configured: %s.",
+			update.Message.CommandArguments(), strings.Join(names, ", ")))
+		msg.ReplyToMessageID = getReplyMessageID(update.Message)
+		send(bot, msg)
+		return
+	}
+
+	t := fmt.Sprintf("<b>%s</b>\n%s", cryptoTitle(d), crypto.For(d).String())
+	if pp := premium.Of(d); len(pp) > 0 {
+		t += "\n" + premium.String(pp)
+	}
+
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, t)
 
 	msg.ParseMode = tgbotapi.ModeHTML
 	msg.ReplyToMessageID = getReplyMessageID(update.Message)
-	msg.ReplyMarkup = &kb
+	msg.ReplyMarkup = cryptoKeyboard(d)
+
+	send(bot, msg)
+}
+
+func coinHandler(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
+	log.Infof("Coin request from %s", update.Message.From)
+
+	if !enabled(bot, update.Message, config.Coins, "coin") {
+		return
+	}
+
+	sym := "BTC"
+	if arg := strings.TrimSpace(update.Message.CommandArguments()); len(arg) > 0 {
+		sym = strings.ToUpper(arg)
+	}
+
+	t := coins.Get().Quote(sym)
+	if !coins.Get().Has(sym) || len(t) == 0 {
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("Unsupported asset %q, use one of: %s.",
+			sym, strings.Join(coins.Get().Symbols(), ", ")))
+		msg.ReplyToMessageID = getReplyMessageID(update.Message)
+		send(bot, msg)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("<b>1 %s equals</b>\n%s\n%s", sym, t, coins.Suffix))
+
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyToMessageID = getReplyMessageID(update.Message)
+
+	send(bot, msg)
+}
+
+func futuresHandler(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
+	log.Infof("Futures request from %s", update.Message.From)
+
+	if !enabled(bot, update.Message, config.Futures, "futures") {
+		return
+	}
+
+	t := "No futures quotes yet."
+	if s := futures.Get().String(); len(s) > 0 {
+		t = fmt.Sprintf("<b>%s</b>\n%s\n%s", futures.Prefix, s, futures.Suffix)
+	}
+
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, t)
+
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyToMessageID = getReplyMessageID(update.Message)
+
+	send(bot, msg)
+}
+
+func routeHandler(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
+	log.Infof("Route request from %s", update.Message.From)
+
+	cfg := config.Get()
+	if !cfg.Providers.Cash.Enabled && !cfg.Providers.Crypto.Enabled {
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, "/route is disabled.")
+		msg.ReplyToMessageID = getReplyMessageID(update.Message)
+		send(bot, msg)
+		return
+	}
+
+	amount, cur, err := route.Parse(strings.Fields(update.Message.CommandArguments()))
+	if err != nil {
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("%s, use e.g. /route 5000 usd.", err))
+		msg.ReplyToMessageID = getReplyMessageID(update.Message)
+		send(bot, msg)
+		return
+	}
+
+	rt, rr := route.New(), []route.Route{}
+	if cfg.Providers.Cash.Enabled && cfg.Providers.Cash.HasCurrency(cur) {
+		if r, ok := rt.ViaBranch(amount, cur); ok {
+			rr = append(rr, r)
+		}
+	}
+
+	if cfg.Providers.Crypto.Enabled {
+		rr = append(rr, rt.ViaCrypto(amount, cur, cfg.Providers.Crypto.ExchangeDirections())...)
+	}
+
+	t := fmt.Sprintf("No route to buy %v %s yet.", amount, html.EscapeString(cur))
+	if len(rr) > 0 {
+		t = fmt.Sprintf("<b>Cheapest routes to %v %s</b>\n%s", amount, cur, route.String(route.Rank(rr), cur))
+	}
+
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, t)
+
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyToMessageID = getReplyMessageID(update.Message)
+	msg.DisableWebPagePreview = true
 
-	bot.Send(msg)
+	send(bot, msg)
+}
+
+func locationHandler(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
+	log.Infof("Location request from %s", update.Message.From)
+
+	if !enabled(bot, update.Message, config.Cash, "cash") {
+		return
+	}
+
+	if !cash.Get().Located() {
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Locations of cash branches are unknown.")
+		msg.ReplyToMessageID = getReplyMessageID(update.Message)
+
+		send(bot, msg)
+		return
+	}
+
+	radius := config.Get().Providers.Cash.Radius
+	l := update.Message.Location
+	buy, sell := cash.Get().Nearest(l.Latitude, l.Longitude, radius)
+
+	t := fmt.Sprintf("No cash branches within %g km.", radius)
+	if len(buy) > 0 || len(sell) > 0 {
+		s := []string{fmt.Sprintf("<b>Nearest cash branches within %g km</b>", radius)}
+		for _, v := range []struct {
+			title string
+			items []string
+		}{{"Buy cash", buy}, {"Sell cash", sell}} {
+			s = append(s, fmt.Sprintf("\n<b>%s</b>", v.title))
+			for i, item := range v.items {
+				s = append(s, fmt.Sprintf("<b>%d</b> %s", i+1, item))
+			}
+		}
+
+		t = strings.Join(s, "\n")
+	}
+
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, t)
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.DisableWebPagePreview = true
+	msg.ReplyToMessageID = getReplyMessageID(update.Message)
+
+	send(bot, msg)
 }
 
 func helpHandler(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
@@ -226,13 +595,13 @@ func helpHandler(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
 
 	msg := tgbotapi.NewMessage(
 		update.Message.Chat.ID,
-		helpCmd,
+		config.Get().Templates.Help,
 	)
 
 	msg.ParseMode = tgbotapi.ModeHTML
 	msg.ReplyToMessageID = getReplyMessageID(update.Message)
 
-	bot.Send(msg)
+	send(bot, msg)
 }
 
 func start(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
@@ -244,14 +613,27 @@ func start(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
 func dashboardHandler(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
 	log.Infof("Dashboard request from %s", update.Message.From)
 
-	t := fmt.Sprintf("<b>%s</b>\n%s<b>%s</b>\n%s\n<b>%s</b>\n%s\n%s",
-		exchange.Prefix, exchange.Get().String(),
-		crypto.Prefix, crypto.Get().String(),
-		cash.Prefix, cash.Get().String(), cash.Suffix)
+	cfg := config.Get()
+
+	t := fmt.Sprintf("<b>%s</b>\n%s", cfg.Templates.Exchange, exchange.Get().String())
+
+	if dd := cfg.Providers.Crypto.ExchangeDirections(); cfg.Providers.Crypto.Enabled && len(dd) > 0 {
+		t += fmt.Sprintf("<b>%s</b>\n%s\n", cryptoTitle(dd[0]), crypto.For(dd[0]).String())
+		if pp := premium.Of(dd[0]); len(pp) > 0 {
+			t += premium.String(pp) + "\n"
+		}
+	}
+
+	if s := coins.Get().String(); cfg.Providers.Coins.Enabled && len(s) > 0 {
+		t += fmt.Sprintf("<b>%s</b>\n%s\n%s\n", coins.Prefix, s, coins.Suffix)
+	}
 
-	if len(cash.Get().BuyBranches()) == 0 || len(cash.Get().SellBranches()) == 0 {
-		t = fmt.Sprintf("<b>%s</b>\n%s", exchange.Prefix, exchange.Get().String())
-		log.Warn("No branches")
+	if cfg.Providers.Cash.Enabled {
+		if len(cash.Get().BuyBranches()) == 0 || len(cash.Get().SellBranches()) == 0 {
+			log.Warn("No branches")
+		} else {
+			t += fmt.Sprintf("<b>%s</b>\n%s\n%s", cfg.Templates.Cash, cash.Get().String(), cfg.Templates.CashSuffix)
+		}
 	}
 
 	msg := tgbotapi.NewMessage(update.Message.Chat.ID, t)
@@ -259,13 +641,17 @@ func dashboardHandler(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
 	msg.ReplyToMessageID = getReplyMessageID(update.Message)
 	msg.ReplyMarkup = &kb
 
-	bot.Send(msg)
+	send(bot, msg)
 }
 
-func onBuy(bot *tgbotapi.BotAPI, cq *tgbotapi.CallbackQuery) {
+func onBuy(bot *tgbotapi.BotAPI, cq *tgbotapi.CallbackQuery, cur bankiru.Currency) {
 	log.Infof("OnBuy request from %s", cq.From)
 
-	bb := cash.Get().BuyBranches()
+	if len(cur) == 0 {
+		cur = bankiru.USD
+	}
+
+	bb := cash.For(cur).BuyBranches()
 	if len(bb) == 0 {
 		log.Warn("No buy branches")
 		return
@@ -282,19 +668,24 @@ func onBuy(bot *tgbotapi.BotAPI, cq *tgbotapi.CallbackQuery) {
 
 	msg := tgbotapi.NewMessage(
 		cq.Message.Chat.ID,
-		strings.Join(append([]string{"<b>Buy cash</b>"}, s...), "\n"),
+		strings.Join(append([]string{fmt.Sprintf("<b>Buy cash</b> (%s)", cur)}, s...), "\n"),
 	)
 
 	msg.ParseMode = tgbotapi.ModeHTML
+	msg.DisableWebPagePreview = true
 	msg.ReplyToMessageID = getReplyMessageID(cq.Message)
 
-	bot.Send(msg)
+	send(bot, msg)
 }
 
-func onSell(bot *tgbotapi.BotAPI, cq *tgbotapi.CallbackQuery) {
+func onSell(bot *tgbotapi.BotAPI, cq *tgbotapi.CallbackQuery, cur bankiru.Currency) {
 	log.Infof("OnSell request from %s", cq.From)
 
-	sb := cash.Get().SellBranches()
+	if len(cur) == 0 {
+		cur = bankiru.USD
+	}
+
+	sb := cash.For(cur).SellBranches()
 	if len(sb) == 0 {
 		log.Warn("No sell branches")
 		return
@@ -311,13 +702,50 @@ func onSell(bot *tgbotapi.BotAPI, cq *tgbotapi.CallbackQuery) {
 
 	msg := tgbotapi.NewMessage(
 		cq.Message.Chat.ID,
-		strings.Join(append([]string{"<b>Sell cash</b>"}, s...), "\n"),
+		strings.Join(append([]string{fmt.Sprintf("<b>Sell cash</b> (%s)", cur)}, s...), "\n"),
+	)
+
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.DisableWebPagePreview = true
+	msg.ReplyToMessageID = getReplyMessageID(cq.Message)
+
+	send(bot, msg)
+}
+
+func onOffers(bot *tgbotapi.BotAPI, cq *tgbotapi.CallbackQuery, direction string) {
+	log.Infof("OnOffers request from %s", cq.From)
+
+	d := bestchange.DefaultDirection
+	if len(direction) > 0 {
+		var err error
+		if d, err = bestchange.ParseDirection(direction); err != nil {
+			log.Warnf("Invalid direction of offers: %v", err)
+			return
+		}
+	}
+
+	oo := crypto.For(d).Offers()
+	if len(oo) == 0 {
+		log.Warn("No offers")
+		return
+	}
+
+	s := []string{}
+	for i, v := range oo {
+		s = append(s, fmt.Sprintf("<b>%d</b> %s", i+1, v))
+	}
+
+	log.Debugln(s)
+
+	msg := tgbotapi.NewMessage(
+		cq.Message.Chat.ID,
+		strings.Join(append([]string{fmt.Sprintf("<b>Top %d offers</b> (%s)", len(oo), d)}, s...), "\n"),
 	)
 
 	msg.ParseMode = tgbotapi.ModeHTML
 	msg.ReplyToMessageID = getReplyMessageID(cq.Message)
 
-	bot.Send(msg)
+	send(bot, msg)
 }
 
 func onHelp(bot *tgbotapi.BotAPI, cq *tgbotapi.CallbackQuery) {
@@ -325,13 +753,119 @@ func onHelp(bot *tgbotapi.BotAPI, cq *tgbotapi.CallbackQuery) {
 
 	msg := tgbotapi.NewMessage(
 		cq.Message.Chat.ID,
-		helpCmd,
+		config.Get().Templates.Help,
 	)
 
 	msg.ParseMode = tgbotapi.ModeHTML
 	msg.ReplyToMessageID = getReplyMessageID(cq.Message)
 
-	bot.Send(msg)
+	send(bot, msg)
+}
+
+// keyboard of cash rates of currency cur.
+func keyboard(cur bankiru.Currency) *tgbotapi.InlineKeyboardMarkup {
+	if cur == bankiru.USD {
+		return &kb
+	}
+
+	m := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Buy cash", "Buy:"+string(cur)),
+			tgbotapi.NewInlineKeyboardButtonData("Sell cash", "Sell:"+string(cur)),
+			tgbotapi.NewInlineKeyboardButtonData("Help", "Help"),
+		),
+	)
+
+	return &m
+}
+
+// cryptoTitle of direction d, which is the configured template for the default one.
+func cryptoTitle(d bestchange.Direction) string {
+	if d == bestchange.DefaultDirection {
+		return config.Get().Templates.Crypto
+	}
+
+	return fmt.Sprintf("%s (%s)", crypto.Title(d), d.City.Title())
+}
+
+// cryptoKeyboard of crypto rates of direction d.
+func cryptoKeyboard(d bestchange.Direction) *tgbotapi.InlineKeyboardMarkup {
+	if d == bestchange.DefaultDirection {
+		return &cryptoKb
+	}
+
+	m := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Top offers", "Offers:"+d.String()),
+			tgbotapi.NewInlineKeyboardButtonData("Help", "Help"),
+		),
+	)
+
+	return &m
+}
+
+// enabled reports whether provider is enabled, otherwise replies to message that its command is disabled.
+func enabled(bot *tgbotapi.BotAPI, message *tgbotapi.Message, provider, command string) bool {
+	if config.Get().Providers.Get(provider).Enabled {
+		return true
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("/%s is disabled.", command))
+	msg.ReplyToMessageID = getReplyMessageID(message)
+
+	send(bot, msg)
+
+	return false
+}
+
+// notifyAdmins sends text to admin chats.
+func notifyAdmins(bot *tgbotapi.BotAPI, text string) {
+	admins := config.Get().Admins
+	if len(admins) == 0 {
+		log.Debugf("No admins to notify: %s", text)
+		return
+	}
+
+	for _, id := range admins {
+		send(bot, tgbotapi.NewMessage(id, text))
+	}
+}
+
+// send message and count failures.
+func send(bot *tgbotapi.BotAPI, c tgbotapi.Chattable) {
+	if _, err := bot.Send(c); err != nil {
+		log.Errorf("Send error: %v", err)
+		metrics.SendFailures.With().Inc()
+	}
+}
+
+// countCommand increments counter of handled commands and callbacks.
+func countCommand(name string) {
+	switch name {
+	case "forex", "moex", "cbrf", "cash", "crypto", "coin", "route", "futures", "help", "start", "dashboard", "location", "Buy", "Sell", "Offers", "Help":
+	default:
+		name = "unknown"
+	}
+
+	metrics.Commands.With(name).Inc()
+}
+
+// serveHTTP serves metrics, health probes and other handlers of mux on addr.
+func serveHTTP(addr string, mux *http.ServeMux) *http.Server {
+	mux.Handle("/metrics", metrics.Handler())
+	mux.Handle("/healthz", health.LiveHandler())
+	mux.Handle("/readyz", health.ReadyHandler())
+
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		log.Infof("Listening on %s", addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Errorf("HTTP server error: %v", err)
+		}
+	}()
+
+	return srv
 }
 
 // getReplyMessageID returns message to reply to.
@@ -1,73 +1,210 @@
 package cash
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"html"
 	"log"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/ivanglie/usdrub-bot/internal/drift"
+	"github.com/ivanglie/usdrub-bot/internal/metrics"
 	"github.com/ivanglie/usdrub-bot/pkg/bankiru-go"
 )
 
 const (
 	Prefix = "Top 10 exchange rates of cash"
 	Suffix = "in branches in Moscow, Russia by Banki.ru"
+
+	source = "bankiru"
 )
 
 // cash represents currency exchange cash of cash.
 type cash struct {
 	sync.RWMutex
 	name         string
-	f            func() (*bankiru.Branches, error)
+	currency     bankiru.Currency
+	city         bankiru.City
+	limit        int
+	maxAge       func() time.Duration
+	f            func(ctx context.Context, city bankiru.City, cur bankiru.Currency, maxAge time.Duration) (*bankiru.Branches, error)
 	branches     []bankiru.Branch
+	stale        int
+	strategy     bankiru.Strategy
 	buyBranches  []string
 	sellBranches []string
-	buyMin       float64
-	buyMax       float64
-	buyAvg       float64
-	sellMin      float64
-	sellMax      float64
-	sellAvg      float64
+	statistic    Statistic
+	buy          Stats
+	sell         Stats
 	err          error
 	errDate      time.Time
 }
 
 var (
 	RateInstance *cash
+	instances    = map[bankiru.Currency]*cash{}
 	lock         = &sync.Mutex{}
 )
 
-// Get returns instance of Rate.
+// Get returns instance of USD Rate.
 func Get() *cash {
+	return For(bankiru.USD)
+}
+
+// For returns instance of Rate of currency cur.
+func For(cur bankiru.Currency) *cash {
 	lock.Lock()
 	defer lock.Unlock()
 
-	if RateInstance == nil {
-		RateInstance = &cash{name: Prefix, f: func() (*bankiru.Branches, error) { return bankiru.NewClient().Rates(bankiru.Moscow) }}
+	r, ok := instances[cur]
+	if !ok {
+		r = &cash{name: fmt.Sprintf("%s (%s)", Prefix, cur), currency: cur, city: bankiru.Moscow, limit: 10, statistic: Range,
+			maxAge: func() time.Duration { return bankiru.DefaultMaxAge },
+			f: func(ctx context.Context, city bankiru.City, cur bankiru.Currency, maxAge time.Duration) (*bankiru.Branches, error) {
+				return bankiru.NewClient().WithContext(ctx).WithMaxAge(maxAge).Rates(city, cur)
+			}}
+		instances[cur] = r
+
+		if cur == bankiru.USD {
+			RateInstance = r
+		}
+	}
+
+	return r
+}
+
+// Currency of the rate.
+func (r *cash) Currency() bankiru.Currency {
+	return r.currency
+}
+
+// Configure city of branches and maximum number of listed branches, 0 means no limit.
+func (r *cash) Configure(city bankiru.City, limit int) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.city != city {
+		r.branches, r.buyBranches, r.sellBranches, r.stale = nil, nil, nil, 0
+		r.buy, r.sell = Stats{}, Stats{}
 	}
 
-	return RateInstance
+	r.city, r.limit = city, limit
+}
+
+// SetStatistic presented by String.
+func (r *cash) SetStatistic(s Statistic) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.statistic = s
 }
 
-// Update exchange rate of cash.
-func (r *cash) Update() {
+// SetMaxAge sets freshness window of quotes, which is evaluated on every update.
+func (r *cash) SetMaxAge(f func() time.Duration) {
 	r.Lock()
 	defer r.Unlock()
 
-	v, err := r.f()
+	r.maxAge = f
+}
+
+// Update exchange rate of cash. Branches are scraped without holding the lock.
+func (r *cash) Update(ctx context.Context) {
+	r.RLock()
+	city, maxAge := r.city, r.maxAge
+	r.RUnlock()
+
+	t := time.Now()
+
+	v, err := r.f(ctx, city, r.currency, maxAge())
+	if ctx.Err() != nil {
+		return
+	}
+
+	if errors.Is(err, bankiru.ErrNoMatch) {
+		err = drift.Get().Observe(r.source(), drift.Scrape{NoMatch: true})
+		metrics.ObserveFetch(source, t, metrics.ErrDrift)
+
+		r.Lock()
+		r.err, r.errDate = err, time.Now()
+		r.Unlock()
+		return
+	}
+
 	if v == nil || err != nil {
 		log.Printf("[ERROR] %s: value=%v, error=%v", r.name, v, err)
+		metrics.ObserveFetch(source, t, metrics.ErrType(err))
 
-		r.err = err
-		r.errDate = time.Now()
+		r.Lock()
+		r.err, r.errDate = err, time.Now()
+		r.Unlock()
 		return
 	}
 
+	// Partial drift is reported, but the parsed branches are used
+	drift.Get().Observe(r.source(), drift.Scrape{Rows: v.Rows, Parsed: len(v.Items) + v.Duplicates + len(v.Stale)})
+
+	buy, sell := branchStats(v.Items)
+
+	r.Lock()
+	defer r.Unlock()
+
+	// City is reconfigured meanwhile
+	if r.city != city {
+		return
+	}
+
+	if len(v.Items) != len(r.branches) || len(v.Stale) != r.stale {
+		log.Printf("[INFO] %s: %d branches of %d rows, %d duplicates, %d stale",
+			r.name, len(v.Items), v.Rows, v.Duplicates, len(v.Stale))
+	}
+
+	r.stale = len(v.Stale)
+
 	r.err = nil
+	r.setStrategy(v.Strategy)
 	r.branches = v.Items
-	r.buyMin, r.sellMin, r.buyMax, r.sellMax, r.buyAvg, r.sellAvg = mma(r.branches)
-	r.buyBranches, r.sellBranches = buyBranches(r.branches), sellBranches(r.branches)
+	r.buy, r.sell = buy, sell
+	if r.buy.Outliers > 0 || r.sell.Outliers > 0 {
+		log.Printf("[WARNING] %s: %d buy and %d sell rates are rejected as outliers", r.name, r.buy.Outliers, r.sell.Outliers)
+	}
+	r.buyBranches, r.sellBranches = limit(buyBranches(r.branches), r.limit), limit(sellBranches(r.branches), r.limit)
+
+	metrics.ObserveFetch(source, t, "")
+	metrics.Rate.With(source, string(r.currency)+"RUB", "buy_avg").Set(r.buy.Avg)
+	metrics.Rate.With(source, string(r.currency)+"RUB", "sell_avg").Set(r.sell.Avg)
+	metrics.Rate.With(source, string(r.currency)+"RUB", "buy_median").Set(r.buy.Median)
+	metrics.Rate.With(source, string(r.currency)+"RUB", "sell_median").Set(r.sell.Median)
+}
+
+// source of the rate by currency, e.g. bankiru:usd.
+func (r *cash) source() string {
+	return source + ":" + strings.ToLower(string(r.currency))
+}
+
+// setStrategy of parsing branches, reporting its changes.
+func (r *cash) setStrategy(s bankiru.Strategy) {
+	if s != r.strategy {
+		log.Printf("[INFO] %s: branches are parsed by %q strategy, was %q", r.name, s, r.strategy)
+
+		if s == bankiru.Classes {
+			log.Printf("[WARNING] %s: branches are parsed by hashed CSS classes only, the parser may need updating", r.name)
+		}
+	}
+
+	r.strategy = s
+
+	for _, v := range []bankiru.Strategy{bankiru.Semantic, bankiru.Classes} {
+		used := 0.0
+		if v == s {
+			used = 1
+		}
+
+		metrics.ParseStrategy.With(r.source(), string(v)).Set(used)
+	}
 }
 
 // String representation of currency exchange cash rate.
@@ -75,8 +212,18 @@ func (r *cash) String() string {
 	r.RLock()
 	defer r.RUnlock()
 
-	return fmt.Sprintf("Buy:\t%.2f .. %.2f RUB (avg %.2f)\nSell:\t%.2f .. %.2f RUB (avg %.2f)",
-		r.buyMax, r.buyMin, r.buyAvg, r.sellMin, r.sellMax, r.sellAvg)
+	s := fmt.Sprintf("Buy:\t%s\nSell:\t%s\nTotal:\t%d branches",
+		r.buy.format(r.statistic, true), r.sell.format(r.statistic, false), len(r.branches))
+
+	if n := r.buy.Outliers + r.sell.Outliers; n > 0 {
+		s += fmt.Sprintf("\n%d rates excluded as outliers", n)
+	}
+
+	if r.stale > 0 {
+		s += fmt.Sprintf("\n%d branches excluded as stale", r.stale)
+	}
+
+	return s
 }
 
 // BuyBranches represented as string.
@@ -95,72 +242,155 @@ func (r *cash) SellBranches() []string {
 	return r.sellBranches
 }
 
-// buyBranches represented as string.
+// BestSell returns the branch selling currency at the lowest rate, which isn't an outlier.
+func (r *cash) BestSell() (bankiru.Branch, bool) {
+	r.RLock()
+	defer r.RUnlock()
+
+	best, ok := bankiru.Branch{}, false
+	for _, b := range inlierBranches(r.branches, sellRate) {
+		if b.Sell > 0 && (!ok || b.Sell < best.Sell) {
+			best, ok = b, true
+		}
+	}
+
+	return best, ok
+}
+
+// Located reports whether coordinates of any branch are known.
+func (r *cash) Located() bool {
+	r.RLock()
+	defer r.RUnlock()
+
+	for _, b := range r.branches {
+		if b.HasLocation() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Nearest returns the best buy and sell branches within radius km of lat, lon, represented as string.
+// Branches are ranked by rate and distance equally, outliers of the rate are excluded.
+func (r *cash) Nearest(lat, lon, radius float64) (buy, sell []string) {
+	r.RLock()
+	defer r.RUnlock()
+
+	bb := within(inlierBranches(r.branches, buyRate), lat, lon, radius)
+	rank(bb, radius, buyRate, true)
+
+	sb := within(inlierBranches(r.branches, sellRate), lat, lon, radius)
+	rank(sb, radius, sellRate, false)
+
+	nearbyString := func(v float64, n nearby) string {
+		return fmt.Sprintf("%.1f km, %s", n.distance, branchString(v, n.Branch))
+	}
+
+	for _, v := range bb {
+		buy = append(buy, nearbyString(v.Buy, v))
+	}
+
+	for _, v := range sb {
+		sell = append(sell, nearbyString(v.Sell, v))
+	}
+
+	return limit(buy, r.limit), limit(sell, r.limit)
+}
+
+// buyRate of branch b.
+func buyRate(b bankiru.Branch) float64 { return b.Buy }
+
+// sellRate of branch b.
+func sellRate(b bankiru.Branch) float64 { return b.Sell }
+
+// inlierBranches returns branches which rate isn't an outlier among rates of all of them.
+func inlierBranches(b []bankiru.Branch, rate func(b bankiru.Branch) float64) []bankiru.Branch {
+	values := make([]float64, 0, len(b))
+	for _, v := range b {
+		values = append(values, rate(v))
+	}
+	inlier := inliers(values)
+
+	kept := []bankiru.Branch{}
+	for _, v := range b {
+		if inlier(rate(v)) {
+			kept = append(kept, v)
+		}
+	}
+
+	return kept
+}
+
+// buyBranches represented as string, outliers of buy rates are excluded.
 func buyBranches(b []bankiru.Branch) []string {
+	b = inlierBranches(b, buyRate)
 	sort.Sort(sort.Reverse(bankiru.ByBuySorter(b)))
 
 	s := []string{}
 	for _, v := range b {
-		s = append(s, fmt.Sprintf("%.2f RUB (%v): %s, %s", v.Buy, v.Updated.Format("02.01.2006 15:04"), v.Bank, v.Subway))
+		s = append(s, branchString(v.Buy, v))
 	}
 
 	return s
 }
 
-// sellBranches represented as string.
+// sellBranches represented as string, outliers of sell rates are excluded.
 func sellBranches(b []bankiru.Branch) []string {
+	b = inlierBranches(b, sellRate)
 	sort.Sort(bankiru.BySellSorter(b))
 
 	s := []string{}
 	for _, v := range b {
-		s = append(s, fmt.Sprintf("%.2f RUB (%v): %s, %s", v.Sell, v.Updated.Format("02.01.2006 15:04"), v.Bank, v.Subway))
+		s = append(s, branchString(v.Sell, v))
 	}
 
 	return s
 }
 
-// mma returns min, max and average values of buy and sell rates.
-func mma(b []bankiru.Branch) (bmin, smin, bmax, smax, bavg, savg float64) {
-	if len(b) == 0 {
-		log.Println("[WARNING] mma: empty branches")
-		return
-	}
+// branchString represents branch with rate v, its details and map link as HTML.
+func branchString(v float64, b bankiru.Branch) string {
+	s := fmt.Sprintf("%.2f RUB (%v): %s, %s", v, b.Updated.Format("02.01.2006 15:04"), b.Bank, b.Subway)
 
-	btotal, stotal := float64(0), float64(0)
+	details := []string{}
+	for _, d := range []string{b.Address, b.Phone, b.Hours} {
+		if len(d) > 0 {
+			details = append(details, html.EscapeString(d))
+		}
+	}
 
-	bb, sb := []bankiru.Branch{}, []bankiru.Branch{}
-	for _, v := range b {
-		bb = append(bb, v)
-		sb = append(sb, v)
+	if u := b.MapURL(); len(u) > 0 {
+		details = append(details, fmt.Sprintf("<a href=\"%s\">Map</a>", html.EscapeString(u)))
 	}
 
-	bmin, bmax = bb[0].Buy, bb[0].Buy
-	for _, v := range bb {
-		if v.Buy < bmin {
-			bmin = v.Buy
-		}
+	if len(details) > 0 {
+		s += "\n" + strings.Join(details, ", ")
+	}
 
-		if v.Buy > bmax {
-			bmax = v.Buy
-		}
+	return s
+}
 
-		btotal += v.Buy
+// limit returns first n items of s, or all of them if n isn't positive.
+func limit(s []string, n int) []string {
+	if n > 0 && len(s) > n {
+		return s[:n]
 	}
 
-	smin, smax = sb[0].Sell, sb[0].Sell
-	for _, v := range sb {
-		if v.Sell < smin {
-			smin = v.Sell
-		}
-
-		if v.Sell > smax {
-			smax = v.Sell
-		}
+	return s
+}
 
-		stotal += v.Sell
+// branchStats returns stats of buy and sell rates.
+func branchStats(b []bankiru.Branch) (buy, sell Stats) {
+	if len(b) == 0 {
+		log.Println("[WARNING] branchStats: empty branches")
+		return
 	}
 
-	bavg, savg = btotal/float64(len(bb)), stotal/float64(len(sb))
+	bv, sv := make([]float64, 0, len(b)), make([]float64, 0, len(b))
+	for _, v := range b {
+		bv = append(bv, v.Buy)
+		sv = append(sv, v.Sell)
+	}
 
-	return
+	return newStats(bv), newStats(sv)
 }
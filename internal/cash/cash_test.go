@@ -1,7 +1,10 @@
 package cash
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -11,7 +14,7 @@ import (
 
 func Test_rate_Update(t *testing.T) {
 	r := Get()
-	r.f = func() (*bankiru.Branches, error) {
+	r.f = func(ctx context.Context, city bankiru.City, cur bankiru.Currency, maxAge time.Duration) (*bankiru.Branches, error) {
 		rates := &bankiru.Branches{
 			Currency: "USD",
 			City:     bankiru.Moscow,
@@ -20,16 +23,25 @@ func Test_rate_Update(t *testing.T) {
 				{Bank: "b", Subway: "s", Currency: "c", Buy: 50.0, Sell: 52.0, Updated: time.Now()},
 				{Bank: "b", Subway: "s", Currency: "c", Buy: 51.0, Sell: 53.0, Updated: time.Now()},
 			},
+			Strategy: bankiru.Semantic,
 		}
 
 		return rates, nil
 	}
 
-	r.Update()
+	r.Update(context.Background())
 	assert.Equal(t, 3, len(r.branches))
+	assert.Equal(t, bankiru.Semantic, r.strategy)
+
+	// Limit
+	r.Configure(bankiru.Moscow, 2)
+	r.Update(context.Background())
+	assert.Equal(t, 3, len(r.branches))
+	assert.Equal(t, 2, len(r.BuyBranches()))
+	assert.Equal(t, 2, len(r.SellBranches()))
 
 	// Error
-	r.f = func() (*bankiru.Branches, error) {
+	r.f = func(ctx context.Context, city bankiru.City, cur bankiru.Currency, maxAge time.Duration) (*bankiru.Branches, error) {
 		rates := &bankiru.Branches{
 			Currency: "USD",
 			City:     bankiru.Moscow,
@@ -42,8 +54,77 @@ func Test_rate_Update(t *testing.T) {
 		return rates, errors.New("error")
 	}
 
-	r.Update()
+	r.Update(context.Background())
+	assert.Equal(t, 3, len(r.branches))
+
+	// Nothing matched
+	r.f = func(ctx context.Context, city bankiru.City, cur bankiru.Currency, maxAge time.Duration) (*bankiru.Branches, error) {
+		return nil, fmt.Errorf("%w: 0 rows", bankiru.ErrNoMatch)
+	}
+
+	r.Update(context.Background())
 	assert.Equal(t, 3, len(r.branches))
+	assert.ErrorContains(t, r.err, "layout drift")
+}
+
+func Test_rate_Update_unlocked(t *testing.T) {
+	r := &cash{name: "test", currency: bankiru.CNY, city: bankiru.Moscow, maxAge: func() time.Duration { return time.Hour }}
+	r.f = func(ctx context.Context, city bankiru.City, cur bankiru.Currency, maxAge time.Duration) (*bankiru.Branches, error) {
+		// Branches are available while scraping
+		assert.Contains(t, r.String(), "Total:\t0 branches")
+
+		// City is changed while scraping
+		r.Configure(bankiru.Novosibirsk, 10)
+
+		return &bankiru.Branches{Items: []bankiru.Branch{{Bank: "b", Buy: 12, Sell: 13, Updated: time.Now()}}}, nil
+	}
+
+	r.Update(context.Background())
+	assert.Empty(t, r.branches)
+	assert.NoError(t, r.err)
+}
+
+func Test_rate_BestSell(t *testing.T) {
+	r := &cash{}
+	_, ok := r.BestSell()
+	assert.False(t, ok)
+
+	r.branches = []bankiru.Branch{{Bank: "a", Sell: 92}, {Bank: "b", Sell: 0}, {Bank: "c", Sell: 91.5}}
+	b, ok := r.BestSell()
+	assert.True(t, ok)
+	assert.Equal(t, "c", b.Bank)
+
+	// Mistyped rate is rejected as outlier
+	r.branches = []bankiru.Branch{{Bank: "a", Sell: 92}, {Bank: "b", Sell: 92.3}, {Bank: "c", Sell: 91.5},
+		{Bank: "d", Sell: 91.8}, {Bank: "e", Sell: 9.2}}
+	b, ok = r.BestSell()
+	assert.True(t, ok)
+	assert.Equal(t, "c", b.Bank)
+}
+
+func Test_branches_outliers(t *testing.T) {
+	b := []bankiru.Branch{{Bank: "a", Buy: 90, Sell: 92}, {Bank: "b", Buy: 90.3, Sell: 92.3}, {Bank: "c", Buy: 89.5, Sell: 91.5},
+		{Bank: "d", Buy: 900, Sell: 91.8}, {Bank: "e", Buy: 89.8, Sell: 9.2}}
+
+	// Mistyped rates are excluded of their own lists only
+	buy := buyBranches(b)
+	assert.Len(t, buy, 4)
+	assert.Contains(t, buy[0], ": b, ")
+
+	sell := sellBranches(b)
+	assert.Len(t, sell, 4)
+	assert.Contains(t, sell[0], ": c, ")
+
+	for i := range b {
+		b[i].Latitude, b[i].Longitude = 55.7558, 37.6173
+	}
+
+	r := &cash{branches: b}
+	nb, ns := r.Nearest(55.7558, 37.6173, 1)
+	assert.Len(t, nb, 4)
+	assert.Len(t, ns, 4)
+	assert.NotContains(t, strings.Join(nb, "\n"), ": d, ")
+	assert.NotContains(t, strings.Join(ns, "\n"), ": e, ")
 }
 
 func Test_rate_String(t *testing.T) {
@@ -51,9 +132,21 @@ func Test_rate_String(t *testing.T) {
 	r.branches = []bankiru.Branch{{Bank: "b", Subway: "s", Currency: "c", Buy: 100.0, Sell: 200.0, Updated: time.Now()}}
 
 	assert.NotEmpty(t, r.String())
+	assert.Contains(t, r.String(), "Total:\t1 branches")
+
+	// Statistic
+	r.buy = Stats{Count: 5, Outliers: 1, Min: 94, Max: 96, Avg: 95, Median: 95.5, P10: 94.2, P90: 95.8, StdDev: 0.5}
+	assert.Contains(t, r.String(), "Buy:\t96.00 .. 94.00 RUB (avg 95.00)")
+	assert.Contains(t, r.String(), "1 rates excluded as outliers")
+
+	r.SetStatistic(Median)
+	assert.Contains(t, r.String(), "Buy:\t95.50 RUB (median, σ 0.50)")
+
+	r.SetStatistic(Percentiles)
+	assert.Contains(t, r.String(), "Buy:\t95.80 .. 94.20 RUB (80% of branches, median 95.50)")
 }
 
-func Test_mma(t *testing.T) {
+func Test_branchStats(t *testing.T) {
 	// Min, max and avg
 	b := []bankiru.Branch{
 		{
@@ -81,24 +174,20 @@ func Test_mma(t *testing.T) {
 		},
 	}
 
-	bmin, smin, bmax, smax, bavg, savg := mma(b)
-	assert.Equal(t, bmin, 12.00)
-	assert.Equal(t, smin, 56.00)
-	assert.Equal(t, bmax, 14.00)
-	assert.Equal(t, smax, 58.00)
-	assert.Equal(t, bavg, 13.00)
-	assert.Equal(t, savg, 57.00)
+	buy, sell := branchStats(b)
+	assert.Equal(t, buy.Min, 12.00)
+	assert.Equal(t, sell.Min, 56.00)
+	assert.Equal(t, buy.Max, 14.00)
+	assert.Equal(t, sell.Max, 58.00)
+	assert.Equal(t, buy.Avg, 13.00)
+	assert.Equal(t, sell.Avg, 57.00)
 
 	// Empty branches
 	b = []bankiru.Branch{}
 
-	bmin, smin, bmax, smax, bavg, savg = mma(b)
-	assert.Equal(t, bmin, 0.00)
-	assert.Equal(t, smin, 0.00)
-	assert.Equal(t, bmax, 0.00)
-	assert.Equal(t, smax, 0.00)
-	assert.Equal(t, bavg, 0.00)
-	assert.Equal(t, savg, 0.00)
+	buy, sell = branchStats(b)
+	assert.Equal(t, Stats{}, buy)
+	assert.Equal(t, Stats{}, sell)
 }
 
 func Test_rate_BuyBranches(t *testing.T) {
@@ -218,3 +307,79 @@ func Test_rate_SellBranches(t *testing.T) {
 
 	assert.Equal(t, len(sb), 6)
 }
+
+func Test_branchString(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Moscow")
+	b := bankiru.Branch{Bank: "b", Subway: "s", Buy: 90.5, Updated: time.Date(2023, time.July, 3, 16, 6, 0, 0, loc)}
+
+	assert.Equal(t, "90.50 RUB (03.07.2023 16:06): b, s", branchString(b.Buy, b))
+
+	b.Address, b.Phone, b.Hours = "Main St & 1st Ave", "+7 (383) 222-22-22", "09:00-20:00"
+	b.Latitude, b.Longitude = 55.03, 82.92
+	assert.Equal(t, "90.50 RUB (03.07.2023 16:06): b, s\n"+
+		"Main St &amp; 1st Ave, +7 (383) 222-22-22, 09:00-20:00, "+
+		"<a href=\"https://yandex.ru/maps/?pt=82.92,55.03&amp;z=16&amp;l=map\">Map</a>", branchString(b.Buy, b))
+}
+
+func Test_rate_Nearest(t *testing.T) {
+	r := &cash{limit: 1, branches: []bankiru.Branch{
+		{Bank: "b1", Buy: 90, Sell: 92, Latitude: 55.7558, Longitude: 37.6173},
+		{Bank: "b2", Buy: 91, Sell: 93, Latitude: 55.7600, Longitude: 37.6200},
+		{Bank: "b3", Buy: 95, Sell: 96},
+	}}
+
+	assert.True(t, r.Located())
+
+	buy, sell := r.Nearest(55.7539, 37.6208, 5)
+	assert.Equal(t, 1, len(buy))
+	assert.Contains(t, buy[0], "b2")
+	assert.Equal(t, 1, len(sell))
+	assert.Contains(t, sell[0], "b1")
+	assert.Contains(t, sell[0], "0.3 km")
+
+	buy, sell = r.Nearest(59.9391, 30.3159, 5)
+	assert.Empty(t, buy)
+	assert.Empty(t, sell)
+
+	r.branches = r.branches[2:]
+	assert.False(t, r.Located())
+}
+
+func TestFor(t *testing.T) {
+	assert.Equal(t, Get(), For(bankiru.USD))
+	assert.Equal(t, RateInstance, Get())
+
+	r := For(bankiru.EUR)
+	assert.NotEqual(t, Get(), r)
+	assert.Equal(t, bankiru.EUR, r.Currency())
+	assert.Equal(t, "bankiru:eur", r.source())
+
+	r.f = func(ctx context.Context, city bankiru.City, cur bankiru.Currency, maxAge time.Duration) (*bankiru.Branches, error) {
+		assert.Equal(t, bankiru.EUR, cur)
+		return &bankiru.Branches{Currency: cur, City: city, Items: []bankiru.Branch{
+			{Bank: "b", Subway: "s", Currency: "EUR", Buy: 92.0, Sell: 95.0, Updated: time.Now()},
+		}}, nil
+	}
+
+	r.Update(context.Background())
+	assert.Equal(t, 92.0, r.buy.Avg)
+	assert.Equal(t, 1, len(r.BuyBranches()))
+}
+
+func Test_rate_SetMaxAge(t *testing.T) {
+	r := For(bankiru.CNY)
+	r.SetMaxAge(func() time.Duration { return 3 * time.Hour })
+
+	r.f = func(ctx context.Context, city bankiru.City, cur bankiru.Currency, maxAge time.Duration) (*bankiru.Branches, error) {
+		assert.Equal(t, 3*time.Hour, maxAge)
+		b := bankiru.Branch{Bank: "b", Subway: "s", Currency: "CNY", Buy: 11.0, Sell: 12.0}
+		return &bankiru.Branches{Currency: cur, City: city,
+			Items: []bankiru.Branch{b},
+			Stale: []bankiru.Stale{{Branch: b, Reason: "older than 3h"}, {Branch: b, Reason: "older than 3h"}},
+		}, nil
+	}
+
+	r.Update(context.Background())
+	assert.Equal(t, 1, len(r.branches))
+	assert.Contains(t, r.String(), "2 branches excluded as stale")
+}
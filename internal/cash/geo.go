@@ -0,0 +1,75 @@
+package cash
+
+import (
+	"math"
+	"sort"
+
+	"github.com/ivanglie/usdrub-bot/pkg/bankiru-go"
+)
+
+// earthRadius in km.
+const earthRadius = 6371.0
+
+// Distance in km between two points by the haversine formula.
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dlat, dlon := rad(lat2-lat1), rad(lon2-lon1)
+	a := math.Sin(dlat/2)*math.Sin(dlat/2) + math.Cos(rad(lat1))*math.Cos(rad(lat2))*math.Sin(dlon/2)*math.Sin(dlon/2)
+
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// nearby is a branch with distance to it in km.
+type nearby struct {
+	bankiru.Branch
+	distance float64
+}
+
+// within returns branches with known coordinates within radius km of lat, lon.
+func within(b []bankiru.Branch, lat, lon, radius float64) []nearby {
+	n := []nearby{}
+	for _, v := range b {
+		if !v.HasLocation() {
+			continue
+		}
+
+		if d := Distance(lat, lon, v.Latitude, v.Longitude); d <= radius {
+			n = append(n, nearby{v, d})
+		}
+	}
+
+	return n
+}
+
+// rank sorts branches by the mean of normalized rate and distance, the best first.
+// Rate is the better the higher it is if higher is true, and the lower otherwise.
+func rank(n []nearby, radius float64, rate func(b bankiru.Branch) float64, higher bool) {
+	if len(n) == 0 {
+		return
+	}
+
+	min, max := rate(n[0].Branch), rate(n[0].Branch)
+	for _, v := range n {
+		min, max = math.Min(min, rate(v.Branch)), math.Max(max, rate(v.Branch))
+	}
+
+	score := func(v nearby) float64 {
+		r := 0.0
+		if max > min {
+			r = (rate(v.Branch) - min) / (max - min)
+			if higher {
+				r = 1 - r
+			}
+		}
+
+		d := 0.0
+		if radius > 0 {
+			d = v.distance / radius
+		}
+
+		return (r + d) / 2
+	}
+
+	sort.SliceStable(n, func(i, j int) bool { return score(n[i]) < score(n[j]) })
+}
@@ -0,0 +1,45 @@
+package cash
+
+import (
+	"testing"
+
+	"github.com/ivanglie/usdrub-bot/pkg/bankiru-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistance(t *testing.T) {
+	// Red Square, Moscow - Palace Square, Saint Petersburg
+	assert.InDelta(t, 634.0, Distance(55.7539, 37.6208, 59.9391, 30.3159), 1)
+	assert.Equal(t, 0.0, Distance(55.7539, 37.6208, 55.7539, 37.6208))
+}
+
+func Test_within(t *testing.T) {
+	b := []bankiru.Branch{
+		{Bank: "near", Latitude: 55.7558, Longitude: 37.6173},
+		{Bank: "far", Latitude: 55.9, Longitude: 37.6173},
+		{Bank: "unknown"},
+	}
+
+	n := within(b, 55.7539, 37.6208, 5)
+	assert.Equal(t, 1, len(n))
+	assert.Equal(t, "near", n[0].Bank)
+	assert.InDelta(t, 0.3, n[0].distance, 0.1)
+}
+
+func Test_rank(t *testing.T) {
+	n := []nearby{
+		{bankiru.Branch{Bank: "far best", Buy: 91}, 4.5},
+		{bankiru.Branch{Bank: "near worst", Buy: 89}, 0.5},
+		{bankiru.Branch{Bank: "near good", Buy: 90.8}, 1},
+	}
+
+	rank(n, 5, func(b bankiru.Branch) float64 { return b.Buy }, true)
+	assert.Equal(t, "near good", n[0].Bank)
+	assert.Equal(t, "far best", n[1].Bank)
+	assert.Equal(t, "near worst", n[2].Bank)
+
+	rank(n, 5, func(b bankiru.Branch) float64 { return b.Buy }, false)
+	assert.Equal(t, "near worst", n[0].Bank)
+
+	rank(nil, 5, func(b bankiru.Branch) float64 { return b.Buy }, true)
+}
@@ -0,0 +1,160 @@
+package cash
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Statistic of rates presented to users.
+type Statistic string
+
+const (
+	// Range is min .. max with average.
+	Range Statistic = "range"
+	// Median with standard deviation.
+	Median Statistic = "median"
+	// Percentiles is p10 .. p90 with median.
+	Percentiles Statistic = "percentiles"
+)
+
+// Statistics is a list of supported statistics.
+var Statistics = []Statistic{Range, Median, Percentiles}
+
+// Supported reports whether the statistic is supported.
+func (s Statistic) Supported() bool {
+	for _, v := range Statistics {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// outlierScore is a threshold of modified z-score, above which a rate is an outlier.
+// See Iglewicz and Hoaglin, How to Detect and Handle Outliers, 1993.
+const outlierScore = 3.5
+
+// Stats of rates, which are computed without outliers.
+type Stats struct {
+	Count    int
+	Outliers int
+	Min      float64
+	Max      float64
+	Avg      float64
+	Median   float64
+	P10      float64
+	P90      float64
+	StdDev   float64
+}
+
+// newStats returns stats of values, rejecting outliers by median absolute deviation.
+func newStats(values []float64) Stats {
+	v, outliers := rejectOutliers(values)
+	if len(v) == 0 {
+		return Stats{Outliers: outliers}
+	}
+
+	sort.Float64s(v)
+
+	s := Stats{Count: len(v), Outliers: outliers, Min: v[0], Max: v[len(v)-1]}
+
+	total := 0.0
+	for _, x := range v {
+		total += x
+	}
+	s.Avg = total / float64(len(v))
+
+	sq := 0.0
+	for _, x := range v {
+		sq += (x - s.Avg) * (x - s.Avg)
+	}
+	s.StdDev = math.Sqrt(sq / float64(len(v)))
+
+	s.Median, s.P10, s.P90 = percentile(v, 50), percentile(v, 10), percentile(v, 90)
+
+	return s
+}
+
+// rejectOutliers returns values with modified z-score not above the threshold and the number of rejected ones.
+func rejectOutliers(values []float64) ([]float64, int) {
+	inlier := inliers(values)
+
+	kept := []float64{}
+	for _, x := range values {
+		if inlier(x) {
+			kept = append(kept, x)
+		}
+	}
+
+	return kept, len(values) - len(kept)
+}
+
+// inliers returns function reporting whether a value has modified z-score among values not above the threshold.
+// If median absolute deviation is zero, mean absolute deviation is used instead.
+func inliers(values []float64) func(x float64) bool {
+	if len(values) < 3 {
+		return func(float64) bool { return true }
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	m := percentile(sorted, 50)
+
+	dev := make([]float64, len(sorted))
+	for i, x := range sorted {
+		dev[i] = math.Abs(x - m)
+	}
+	sort.Float64s(dev)
+
+	// Scale factors make both deviations consistent with standard deviation of normal distribution
+	scale := 1.4826 * percentile(dev, 50)
+	if scale == 0 {
+		total := 0.0
+		for _, d := range dev {
+			total += d
+		}
+		scale = 1.253314 * total / float64(len(dev))
+	}
+
+	if scale == 0 {
+		return func(float64) bool { return true }
+	}
+
+	return func(x float64) bool { return math.Abs(x-m)/scale <= outlierScore }
+}
+
+// percentile p of sorted values by linear interpolation between closest ranks.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	pos := p / 100 * float64(len(sorted)-1)
+	i := int(pos)
+	if i >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+
+	return sorted[i] + (pos-float64(i))*(sorted[i+1]-sorted[i])
+}
+
+// format stats by statistic s, best rates first if desc is set.
+func (s Stats) format(st Statistic, desc bool) string {
+	lo, hi := s.Min, s.Max
+	p10, p90 := s.P10, s.P90
+	if desc {
+		lo, hi = hi, lo
+		p10, p90 = p90, p10
+	}
+
+	switch st {
+	case Median:
+		return fmt.Sprintf("%.2f RUB (median, σ %.2f)", s.Median, s.StdDev)
+	case Percentiles:
+		return fmt.Sprintf("%.2f .. %.2f RUB (80%% of branches, median %.2f)", p10, p90, s.Median)
+	default:
+		return fmt.Sprintf("%.2f .. %.2f RUB (avg %.2f)", lo, hi, s.Avg)
+	}
+}
@@ -0,0 +1,48 @@
+package cash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_newStats(t *testing.T) {
+	// Typo of a buy rate
+	s := newStats([]float64{95.1, 94.8, 9.5, 95.3, 94.9, 95.0})
+	assert.Equal(t, 5, s.Count)
+	assert.Equal(t, 1, s.Outliers)
+	assert.Equal(t, 94.8, s.Min)
+	assert.Equal(t, 95.3, s.Max)
+	assert.InDelta(t, 95.02, s.Avg, 1e-9)
+	assert.Equal(t, 95.0, s.Median)
+	assert.InDelta(t, 94.84, s.P10, 1e-9)
+	assert.InDelta(t, 95.22, s.P90, 1e-9)
+	assert.InDelta(t, 0.1720, s.StdDev, 1e-4)
+
+	// Equal rates, but one
+	s = newStats([]float64{95, 95, 95, 95, 950})
+	assert.Equal(t, 1, s.Outliers)
+	assert.Equal(t, 95.0, s.Max)
+
+	// No outliers
+	s = newStats([]float64{90, 95, 100})
+	assert.Equal(t, 0, s.Outliers)
+	assert.Equal(t, 95.0, s.Avg)
+
+	// Empty
+	assert.Equal(t, Stats{}, newStats(nil))
+}
+
+func Test_percentile(t *testing.T) {
+	v := []float64{1, 2, 3, 4, 5}
+	assert.Equal(t, 1.0, percentile(v, 0))
+	assert.Equal(t, 3.0, percentile(v, 50))
+	assert.Equal(t, 5.0, percentile(v, 100))
+	assert.InDelta(t, 1.4, percentile(v, 10), 1e-9)
+	assert.Equal(t, 0.0, percentile(nil, 50))
+}
+
+func TestStatistic_Supported(t *testing.T) {
+	assert.True(t, Median.Supported())
+	assert.False(t, Statistic("mean").Supported())
+}
@@ -0,0 +1,253 @@
+package coins
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ivanglie/usdrub-bot/internal/history"
+	"github.com/ivanglie/usdrub-bot/internal/httputil"
+	"github.com/ivanglie/usdrub-bot/internal/metrics"
+	"github.com/ivanglie/usdrub-bot/pkg/coingate-go"
+)
+
+const (
+	Prefix = "Crypto assets"
+	Suffix = "by CoinGate"
+
+	source = "coingate"
+)
+
+// Period of price change.
+const Period = 24 * time.Hour
+
+// Quotes are currencies of prices.
+var Quotes = []string{"RUB", "USD"}
+
+// store of history series.
+type store interface {
+	Add(name string, t time.Time, v float64)
+	At(name string, t time.Time) (history.Point, bool)
+}
+
+// coins represents prices of crypto assets.
+type coins struct {
+	sync.RWMutex
+	symbols  []string
+	history  store
+	interval time.Duration // Interval of updates, which is the maximum age of the price of Period ago.
+	f        func(ctx context.Context) (*coingate.Rates, error)
+	rates    *coingate.Rates
+	updated  time.Time
+	err      error
+	errDate  time.Time
+}
+
+var (
+	RateInstance *coins
+	lock         = &sync.Mutex{}
+)
+
+// Get returns instance of Rate.
+func Get() *coins {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if RateInstance == nil {
+		RateInstance = &coins{symbols: []string{"BTC", "ETH", "USDT"}, history: history.Get(), interval: 5 * time.Minute,
+			f: func(ctx context.Context) (*coingate.Rates, error) {
+				c := coingate.NewClient()
+				c.SetFetchFunction(httputil.Get(ctx))
+				return c.GetRates()
+			}}
+	}
+
+	return RateInstance
+}
+
+// Configure symbols of listed assets, e.g. BTC.
+func (r *coins) Configure(symbols []string) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.symbols = append([]string(nil), symbols...)
+}
+
+// SetInterval of updates, which is the maximum age of the price of Period ago.
+func (r *coins) SetInterval(d time.Duration) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.interval = d
+}
+
+// Update prices of every asset by one request of the rate matrix. Rates are fetched without holding the lock.
+func (r *coins) Update(ctx context.Context) {
+	t := time.Now()
+
+	v, err := r.f(ctx)
+	if ctx.Err() != nil {
+		return
+	}
+
+	if v == nil || err != nil {
+		log.Printf("[ERROR] %s: value=%v, error=%v", Prefix, v, err)
+		metrics.ObserveFetch(source, t, metrics.ErrType(err))
+
+		r.Lock()
+		r.err, r.errDate = err, time.Now()
+		r.Unlock()
+		return
+	}
+
+	r.Lock()
+	r.err = nil
+	r.rates = v
+	r.updated = time.Now()
+	updated, symbols := r.updated, r.symbols
+	r.Unlock()
+
+	metrics.ObserveFetch(source, t, "")
+	for _, s := range symbols {
+		for _, q := range Quotes {
+			if p, ok := v.Merchant.Rate(s, q); ok {
+				metrics.Rate.With(source, s+q, "merchant").Set(p)
+				r.history.Add(Series(s, q), updated, p)
+			}
+		}
+	}
+}
+
+// Series returns name of history series of price of asset symbol in quote currency, e.g. coin:BTC:RUB.
+func Series(symbol, quote string) string {
+	return fmt.Sprintf("coin:%s:%s", symbol, quote)
+}
+
+// Has reports whether asset symbol is listed.
+func (r *coins) Has(symbol string) bool {
+	r.RLock()
+	defer r.RUnlock()
+
+	for _, s := range r.symbols {
+		if s == symbol {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Symbols of listed assets.
+func (r *coins) Symbols() []string {
+	r.RLock()
+	defer r.RUnlock()
+
+	return append([]string(nil), r.symbols...)
+}
+
+// Price of asset symbol in quote currency, if it's known.
+func (r *coins) Price(symbol, quote string) (float64, bool) {
+	r.RLock()
+	defer r.RUnlock()
+
+	if r.rates == nil {
+		return 0, false
+	}
+
+	return r.rates.Merchant.Rate(symbol, quote)
+}
+
+// Change of price of asset symbol in quote currency over Period in percent,
+// if history has a price of that time, which isn't older than the interval of updates.
+func (r *coins) Change(symbol, quote string) (float64, bool) {
+	p, ok := r.Price(symbol, quote)
+	if !ok {
+		return 0, false
+	}
+
+	r.RLock()
+	since, interval := r.updated.Add(-Period), r.interval
+	r.RUnlock()
+
+	old, ok := r.history.At(Series(symbol, quote), since)
+	if !ok || old.Value == 0 || since.Sub(old.Time) > interval {
+		return 0, false
+	}
+
+	return (p - old.Value) / old.Value * 100, true
+}
+
+// Trader buy and sell rates of asset symbol in quote currency, if they're known.
+func (r *coins) Trader(symbol, quote string) (coingate.TraderRate, bool) {
+	r.RLock()
+	defer r.RUnlock()
+
+	if r.rates == nil {
+		return coingate.TraderRate{}, false
+	}
+
+	buy, okBuy := r.rates.Buy.Rate(symbol, quote)
+	sell, okSell := r.rates.Sell.Rate(symbol, quote)
+
+	return coingate.TraderRate{Buy: buy, Sell: sell}, okBuy && okSell
+}
+
+// String representation of prices of every asset in every quote currency.
+func (r *coins) String() string {
+	r.RLock()
+	symbols := r.symbols
+	r.RUnlock()
+
+	s := []string{}
+	for _, sym := range symbols {
+		pp := []string{}
+		for _, q := range Quotes {
+			if p, ok := r.Price(sym, q); ok {
+				pp = append(pp, fmt.Sprintf("%s %s", price(p), q))
+			}
+		}
+
+		if len(pp) > 0 {
+			s = append(s, fmt.Sprintf("%s:\t%s", sym, strings.Join(pp, ", ")))
+		}
+	}
+
+	return strings.Join(s, "\n")
+}
+
+// Quote returns price of asset symbol in every quote currency with its change and trader rates, one per line, e.g.
+// 5800000 RUB (+2.31% in 24h), bid 5750000, ask 5850000, spread 1.74%. It's empty if the price is unknown.
+func (r *coins) Quote(symbol string) string {
+	s := []string{}
+	for _, q := range Quotes {
+		p, ok := r.Price(symbol, q)
+		if !ok {
+			continue
+		}
+
+		line := fmt.Sprintf("%s %s", price(p), q)
+		if c, ok := r.Change(symbol, q); ok {
+			line += fmt.Sprintf(" (%+.2f%% in 24h)", c)
+		}
+
+		if t, ok := r.Trader(symbol, q); ok {
+			line += fmt.Sprintf(", bid %s, ask %s, spread %.2f%%", price(t.Sell), price(t.Buy), t.Spread())
+		}
+
+		s = append(s, line)
+	}
+
+	return strings.Join(s, "\n")
+}
+
+// price with precision by its magnitude, e.g. 5800000 or 1.00.
+func price(v float64) string {
+	if v >= 1000 {
+		return fmt.Sprintf("%.0f", v)
+	}
+
+	return fmt.Sprintf("%.2f", v)
+}
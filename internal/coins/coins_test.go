@@ -0,0 +1,100 @@
+package coins
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ivanglie/usdrub-bot/internal/history"
+	"github.com/ivanglie/usdrub-bot/pkg/coingate-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_coins_Update(t *testing.T) {
+	calls := 0
+	r := &coins{symbols: []string{"BTC", "ETH", "USDT"}, history: history.New(),
+		f: func(ctx context.Context) (*coingate.Rates, error) {
+			calls++
+			return &coingate.Rates{
+				Merchant: coingate.Matrix{
+					"BTC":  {"RUB": 5800000, "USD": 62000},
+					"ETH":  {"RUB": 290000, "USD": 3100},
+					"USDT": {"RUB": 93.5, "USD": 1},
+				},
+				Buy:  coingate.Matrix{"BTC": {"RUB": 5850000}},
+				Sell: coingate.Matrix{"BTC": {"RUB": 5750000}},
+			}, nil
+		}}
+
+	r.Update(context.Background())
+	assert.Equal(t, 1, calls)
+
+	p, ok := r.Price("ETH", "USD")
+	assert.True(t, ok)
+	assert.Equal(t, 3100.0, p)
+
+	tr, ok := r.Trader("BTC", "RUB")
+	assert.True(t, ok)
+	assert.Equal(t, coingate.TraderRate{Buy: 5850000, Sell: 5750000}, tr)
+
+	_, ok = r.Trader("ETH", "RUB")
+	assert.False(t, ok)
+
+	assert.Equal(t, "5800000 RUB, bid 5750000, ask 5850000, spread 1.74%\n62000 USD", r.Quote("BTC"))
+
+	assert.Equal(t, "BTC:\t5800000 RUB, 62000 USD\nETH:\t290000 RUB, 3100 USD\nUSDT:\t93.50 RUB, 1.00 USD", r.String())
+
+	r.Configure([]string{"BTC", "DOGE"})
+	assert.Equal(t, "BTC:\t5800000 RUB, 62000 USD", r.String())
+
+	// Error keeps the previous prices
+	r.f = func(ctx context.Context) (*coingate.Rates, error) {
+		return nil, errors.New("error")
+	}
+
+	r.Update(context.Background())
+	assert.Error(t, r.err)
+
+	p, _ = r.Price("BTC", "RUB")
+	assert.Equal(t, 5800000.0, p)
+}
+
+func Test_coins_Quote(t *testing.T) {
+	h := history.New()
+	r := &coins{symbols: []string{"ETH"}, history: h, interval: time.Hour,
+		f: func(ctx context.Context) (*coingate.Rates, error) {
+			return &coingate.Rates{Merchant: coingate.Matrix{"ETH": {"RUB": 290000, "USD": 3100}}}, nil
+		}}
+
+	// Too old to compare with
+	h.Add(Series("ETH", "RUB"), time.Now().Add(-Period-2*time.Hour), 200000)
+	r.Update(context.Background())
+	_, ok := r.Change("ETH", "RUB")
+	assert.False(t, ok)
+
+	h.Add(Series("ETH", "RUB"), time.Now().Add(-Period-30*time.Minute), 250000)
+	r.Update(context.Background())
+
+	assert.True(t, r.Has("ETH"))
+	assert.False(t, r.Has("BTC"))
+	assert.Equal(t, []string{"ETH"}, r.Symbols())
+
+	c, ok := r.Change("ETH", "RUB")
+	assert.True(t, ok)
+	assert.InDelta(t, 16.0, c, 1e-9)
+
+	_, ok = r.Change("ETH", "USD")
+	assert.False(t, ok)
+
+	assert.Equal(t, "290000 RUB (+16.00% in 24h)\n3100 USD", r.Quote("ETH"))
+	assert.Empty(t, r.Quote("BTC"))
+}
+
+func TestGet(t *testing.T) {
+	assert.Equal(t, Get(), Get())
+}
+
+func TestSeries(t *testing.T) {
+	assert.Equal(t, "coin:BTC:RUB", Series("BTC", "RUB"))
+}
@@ -0,0 +1,350 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/ivanglie/usdrub-bot/internal/cash"
+	"github.com/ivanglie/usdrub-bot/internal/crypto"
+	"github.com/ivanglie/usdrub-bot/internal/exchange"
+	"github.com/ivanglie/usdrub-bot/pkg/bankiru-go"
+	"github.com/ivanglie/usdrub-bot/pkg/bestchange-go"
+	"github.com/ivanglie/usdrub-bot/pkg/moex-go"
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// Provider names.
+const (
+	Forex   = "forex"
+	MOEX    = "moex"
+	CBRF    = "cbrf"
+	Cash    = "cash"
+	Crypto  = "crypto"
+	Coins   = "coins"
+	Futures = "futures"
+)
+
+// Names of providers.
+var Names = []string{Forex, MOEX, CBRF, Cash, Crypto, Coins, Futures}
+
+// Days policies.
+const (
+	EveryDay    = "every"
+	TradingDays = "trading"
+)
+
+// Config of the bot.
+type Config struct {
+	Listen    string        `yaml:"listen"`   // HTTP listen address.
+	Storage   string        `yaml:"storage"`  // Directory of persistent stores.
+	Location  string        `yaml:"location"` // Time zone of schedules.
+	Calendar  string        `yaml:"calendar"` // Calendar file with extra holidays, working and non-trading days.
+	Jitter    time.Duration `yaml:"jitter"`   // Maximum random delay before each update.
+	Admins    []int64       `yaml:"admins"`   // Telegram IDs of admin chats.
+	Providers Providers     `yaml:"providers"`
+	Templates Templates     `yaml:"templates"`
+}
+
+// Providers of rates.
+type Providers struct {
+	Forex   Provider `yaml:"forex"`
+	MOEX    Provider `yaml:"moex"`
+	CBRF    Provider `yaml:"cbrf"`
+	Cash    Provider `yaml:"cash"`
+	Crypto  Provider `yaml:"crypto"`
+	Coins   Provider `yaml:"coins"`
+	Futures Provider `yaml:"futures"`
+}
+
+// Provider of rates.
+type Provider struct {
+	Enabled  bool    `yaml:"enabled"`
+	Schedule string  `yaml:"schedule"` // Cron spec, see https://crontab.guru/.
+	Days     string  `yaml:"days"`     // Days of updates: every or trading.
+	Pair     string  `yaml:"pair"`     // Currency pair, e.g. USD/RUB.
+	City     string  `yaml:"city"`     // City, e.g. moskva.
+	Limit    int     `yaml:"limit"`    // Maximum number of listed items.
+	Radius   float64 `yaml:"radius"`   // Search radius of nearest branches in km.
+
+	Currencies []string `yaml:"currencies"` // Currencies of cash rates or crypto assets, e.g. USD or BTC.
+	Statistic  string   `yaml:"statistic"`  // Statistic of cash rates: range, median or percentiles.
+	Directions []string `yaml:"directions"` // BestChange directions, e.g. cash-ruble-to-tether-trc20-in-msk.
+	Assets     []string `yaml:"assets"`     // Futures assets, e.g. Si.
+
+	PremiumAlert float64 `yaml:"premium_alert"` // Alert threshold of USDT premium in percent, 0 disables alerts.
+
+	MaxAge        time.Duration `yaml:"max_age"`         // Freshness window of quotes.
+	WorkdayMaxAge time.Duration `yaml:"workday_max_age"` // Freshness window of quotes on workdays, 0 means max_age.
+}
+
+// Templates of messages.
+type Templates struct {
+	Help         string `yaml:"help"`
+	Exchange     string `yaml:"exchange"`
+	Cash         string `yaml:"cash"`
+	CashSuffix   string `yaml:"cash_suffix"`
+	Crypto       string `yaml:"crypto"`
+	CryptoSuffix string `yaml:"crypto_suffix"`
+}
+
+var (
+	pairRe   = regexp.MustCompile(`^[A-Z]{3,5}/[A-Z]{3,5}$`)
+	symbolRe = regexp.MustCompile(`^[A-Z0-9]{2,10}$`)
+
+	configInstance *Config
+	lock           = &sync.RWMutex{}
+)
+
+// Get returns current config.
+func Get() *Config {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	if configInstance == nil {
+		return Default()
+	}
+
+	return configInstance
+}
+
+// Set current config.
+func Set(c *Config) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	configInstance = c
+}
+
+// Default returns config with default values.
+func Default() *Config {
+	return &Config{
+		Listen:   ":8080",
+		Location: "Europe/Moscow",
+		Jitter:   10 * time.Second,
+		Providers: Providers{
+			Forex: Provider{Enabled: true, Schedule: "* * * * *", Days: EveryDay, Pair: "USD/RUB"},
+			MOEX:  Provider{Enabled: true, Schedule: "* 10-23 * * *", Days: TradingDays, Pair: "USD/RUB"},
+			CBRF:  Provider{Enabled: true, Schedule: "0 * * * *", Days: EveryDay, Pair: "USD/RUB"},
+			Cash: Provider{Enabled: true, Schedule: "*/10 * * * *", Days: EveryDay, City: "moskva", Limit: 10, Radius: 3,
+				Currencies: []string{"USD", "EUR", "CNY"}, Statistic: string(cash.Range),
+				MaxAge: 24 * time.Hour, WorkdayMaxAge: 3 * time.Hour},
+			Crypto: Provider{Enabled: true, Schedule: "*/5 * * * *", Days: EveryDay,
+				Directions: []string{"cash-ruble-to-tether-trc20-in-msk", "tether-trc20-to-cash-ruble-in-msk",
+					"tether-trc20-to-cash-dollar-in-msk"}},
+			Coins: Provider{Enabled: true, Schedule: "*/5 * * * *", Days: EveryDay, Currencies: []string{"BTC", "ETH", "USDT"}},
+			Futures: Provider{Enabled: true, Schedule: "*/5 10-23 * * *", Days: TradingDays,
+				Assets: []string{moex.Si, moex.CR}},
+		},
+		Templates: Templates{
+			Help: "Just use /forex, /moex, /cbrf, /cash [usd|eur|cny], /crypto [buy|sell] [city] [network], /coin [btc|eth], /route 5000 usd, /futures and /dashboard command, " +
+				"or send your location to find the nearest cash branches.",
+			Exchange:     exchange.Prefix,
+			Cash:         cash.Prefix,
+			CashSuffix:   cash.Suffix,
+			Crypto:       crypto.Prefix,
+			CryptoSuffix: crypto.Suffix,
+		},
+	}
+}
+
+// Load config file at path over a copy of base, and validate it.
+// Unknown fields are errors, omitted fields keep values of base.
+func Load(path string, base *Config) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := base.Copy()
+
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return c, nil
+}
+
+// Copy returns deep copy of the config.
+func (c *Config) Copy() *Config {
+	cp := *c
+	cp.Admins = append([]int64(nil), c.Admins...)
+	cp.Providers.Cash.Currencies = append([]string(nil), c.Providers.Cash.Currencies...)
+	cp.Providers.Crypto.Directions = append([]string(nil), c.Providers.Crypto.Directions...)
+	cp.Providers.Coins.Currencies = append([]string(nil), c.Providers.Coins.Currencies...)
+	cp.Providers.Futures.Assets = append([]string(nil), c.Providers.Futures.Assets...)
+
+	return &cp
+}
+
+// Validate config.
+func (c *Config) Validate() error {
+	if len(c.Listen) == 0 {
+		return errors.New("listen is empty")
+	}
+
+	if _, err := time.LoadLocation(c.Location); err != nil {
+		return fmt.Errorf("location: %v", err)
+	}
+
+	if c.Jitter < 0 {
+		return fmt.Errorf("jitter is negative: %v", c.Jitter)
+	}
+
+	for _, id := range c.Admins {
+		if id == 0 {
+			return errors.New("admins: chat ID is zero")
+		}
+	}
+
+	for _, name := range Names {
+		if err := c.Providers.Get(name).validate(); err != nil {
+			return fmt.Errorf("providers.%s: %v", name, err)
+		}
+	}
+
+	for _, name := range []string{Forex, MOEX, CBRF} {
+		if p := c.Providers.Get(name); !pairRe.MatchString(p.Pair) {
+			return fmt.Errorf("providers.%s: invalid pair %q, want e.g. USD/RUB", name, p.Pair)
+		}
+	}
+
+	if len(c.Providers.Cash.City) == 0 {
+		return errors.New("providers.cash: city is empty")
+	}
+
+	if c.Providers.Cash.Limit <= 0 {
+		return fmt.Errorf("providers.cash: limit must be positive, got %d", c.Providers.Cash.Limit)
+	}
+
+	if len(c.Providers.Cash.Currencies) == 0 {
+		return errors.New("providers.cash: currencies are empty")
+	}
+
+	for _, v := range c.Providers.Cash.Currencies {
+		if !bankiru.Currency(v).Supported() {
+			return fmt.Errorf("providers.cash: unsupported currency %q, want one of %v", v, bankiru.Currencies)
+		}
+	}
+
+	if c.Providers.Cash.Radius <= 0 {
+		return fmt.Errorf("providers.cash: radius must be positive, got %v", c.Providers.Cash.Radius)
+	}
+
+	if !cash.Statistic(c.Providers.Cash.Statistic).Supported() {
+		return fmt.Errorf("providers.cash: unsupported statistic %q, want one of %v", c.Providers.Cash.Statistic, cash.Statistics)
+	}
+
+	if c.Providers.Cash.MaxAge <= 0 {
+		return fmt.Errorf("providers.cash: max_age must be positive, got %v", c.Providers.Cash.MaxAge)
+	}
+
+	if c.Providers.Cash.WorkdayMaxAge < 0 {
+		return fmt.Errorf("providers.cash: workday_max_age must not be negative, got %v", c.Providers.Cash.WorkdayMaxAge)
+	}
+
+	if c.Providers.Crypto.PremiumAlert < 0 {
+		return fmt.Errorf("providers.crypto: premium_alert must not be negative, got %v", c.Providers.Crypto.PremiumAlert)
+	}
+
+	if len(c.Providers.Crypto.Directions) == 0 {
+		return errors.New("providers.crypto: directions are empty")
+	}
+
+	for _, v := range c.Providers.Crypto.Directions {
+		if _, err := bestchange.ParseDirection(v); err != nil {
+			return fmt.Errorf("providers.crypto: %v", err)
+		}
+	}
+
+	if len(c.Providers.Coins.Currencies) == 0 {
+		return errors.New("providers.coins: currencies are empty")
+	}
+
+	for _, v := range c.Providers.Coins.Currencies {
+		if !symbolRe.MatchString(v) {
+			return fmt.Errorf("providers.coins: invalid currency %q, want e.g. BTC", v)
+		}
+	}
+
+	if len(c.Providers.Futures.Assets) == 0 {
+		return errors.New("providers.futures: assets are empty")
+	}
+
+	for _, v := range c.Providers.Futures.Assets {
+		if len(moex.FuturesPair(v)) == 0 {
+			return fmt.Errorf("providers.futures: unsupported asset %q, want %s or %s", v, moex.Si, moex.CR)
+		}
+	}
+
+	return nil
+}
+
+// Get returns provider by name, or nil if it's unknown.
+func (p *Providers) Get(name string) *Provider {
+	switch name {
+	case Forex:
+		return &p.Forex
+	case MOEX:
+		return &p.MOEX
+	case CBRF:
+		return &p.CBRF
+	case Cash:
+		return &p.Cash
+	case Crypto:
+		return &p.Crypto
+	case Coins:
+		return &p.Coins
+	case Futures:
+		return &p.Futures
+	}
+
+	return nil
+}
+
+// HasCurrency reports whether the provider has currency cur.
+func (p *Provider) HasCurrency(cur string) bool {
+	for _, v := range p.Currencies {
+		if v == cur {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ExchangeDirections returns parsed BestChange directions of the provider, skipping invalid ones.
+func (p *Provider) ExchangeDirections() []bestchange.Direction {
+	dd := []bestchange.Direction{}
+	for _, v := range p.Directions {
+		if d, err := bestchange.ParseDirection(v); err == nil {
+			dd = append(dd, d)
+		}
+	}
+
+	return dd
+}
+
+// validate common fields of provider.
+func (p *Provider) validate() error {
+	if _, err := cron.ParseStandard(p.Schedule); err != nil {
+		return fmt.Errorf("schedule: %v", err)
+	}
+
+	if p.Days != EveryDay && p.Days != TradingDays {
+		return fmt.Errorf("days must be %q or %q, got %q", EveryDay, TradingDays, p.Days)
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ivanglie/usdrub-bot/pkg/bestchange-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefault(t *testing.T) {
+	assert.NoError(t, Default().Validate())
+}
+
+func TestGet(t *testing.T) {
+	assert.Equal(t, Default(), Get())
+
+	c := Default()
+	c.Listen = ":9090"
+	Set(c)
+	defer Set(nil)
+
+	assert.Equal(t, ":9090", Get().Listen)
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yml")
+
+	data := `
+listen: ":9090"
+storage: ` + filepath.Join(dir, "data") + `
+jitter: 30s
+admins: [42]
+providers:
+  moex:
+    pair: CNY/RUB
+    schedule: "*/5 10-19 * * *"
+  cash:
+    city: sankt-peterburg
+    limit: 5
+    currencies: [USD, CNY]
+    workday_max_age: 2h
+    statistic: median
+  crypto:
+    enabled: false
+    directions: [tether-erc20-to-cash-ruble-in-spb]
+    premium_alert: 5
+  coins:
+    currencies: [BTC]
+  futures:
+    assets: [CR]
+templates:
+  help: Help!
+`
+	assert.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+
+	base := Default()
+	c, err := Load(path, base)
+	assert.NoError(t, err)
+
+	assert.Equal(t, ":9090", c.Listen)
+	assert.Equal(t, 30*time.Second, c.Jitter)
+	assert.Equal(t, []int64{42}, c.Admins)
+	assert.Equal(t, "CNY/RUB", c.Providers.MOEX.Pair)
+	assert.Equal(t, "*/5 10-19 * * *", c.Providers.MOEX.Schedule)
+	assert.Equal(t, TradingDays, c.Providers.MOEX.Days)
+	assert.Equal(t, "sankt-peterburg", c.Providers.Cash.City)
+	assert.Equal(t, 5, c.Providers.Cash.Limit)
+	assert.Equal(t, []string{"USD", "CNY"}, c.Providers.Cash.Currencies)
+	assert.Equal(t, "median", c.Providers.Cash.Statistic)
+	assert.Equal(t, 24*time.Hour, c.Providers.Cash.MaxAge)
+	assert.Equal(t, 2*time.Hour, c.Providers.Cash.WorkdayMaxAge)
+	assert.True(t, c.Providers.Cash.HasCurrency("CNY"))
+	assert.False(t, c.Providers.Cash.HasCurrency("EUR"))
+	assert.False(t, c.Providers.Crypto.Enabled)
+	assert.Equal(t, 5.0, c.Providers.Crypto.PremiumAlert)
+	assert.Equal(t, []bestchange.Direction{{Give: bestchange.USDTERC20, Get: bestchange.CashRUB, City: bestchange.SaintPetersburg}},
+		c.Providers.Crypto.ExchangeDirections())
+	assert.True(t, c.Providers.Forex.Enabled)
+	assert.Equal(t, []string{"BTC"}, c.Providers.Coins.Currencies)
+	assert.Equal(t, "*/5 * * * *", c.Providers.Coins.Schedule)
+	assert.Equal(t, []string{"CR"}, c.Providers.Futures.Assets)
+	assert.Equal(t, TradingDays, c.Providers.Futures.Days)
+	assert.Equal(t, "Help!", c.Templates.Help)
+	assert.Equal(t, filepath.Join(dir, "data"), c.Storage)
+	assert.NoDirExists(t, c.Storage)
+
+	// Base is unchanged
+	assert.Equal(t, Default(), base)
+
+	// Not found
+	_, err = Load(filepath.Join(dir, "none.yml"), base)
+	assert.Error(t, err)
+
+	// Unknown field
+	assert.NoError(t, os.WriteFile(path, []byte("lisen: \":9090\"\n"), 0o644))
+	_, err = Load(path, base)
+	assert.ErrorContains(t, err, "lisen")
+
+	// Invalid
+	assert.NoError(t, os.WriteFile(path, []byte("providers:\n  cbrf:\n    schedule: hourly\n"), 0o644))
+	_, err = Load(path, base)
+	assert.ErrorContains(t, err, "providers.cbrf")
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		f    func(c *Config)
+	}{
+		{"listen", func(c *Config) { c.Listen = "" }},
+		{"location", func(c *Config) { c.Location = "Mars/Olympus" }},
+		{"jitter", func(c *Config) { c.Jitter = -time.Second }},
+		{"admins", func(c *Config) { c.Admins = []int64{0} }},
+		{"schedule", func(c *Config) { c.Providers.Forex.Schedule = "* * *" }},
+		{"days", func(c *Config) { c.Providers.MOEX.Days = "weekends" }},
+		{"pair", func(c *Config) { c.Providers.CBRF.Pair = "usdrub" }},
+		{"city", func(c *Config) { c.Providers.Cash.City = "" }},
+		{"limit", func(c *Config) { c.Providers.Cash.Limit = 0 }},
+		{"radius", func(c *Config) { c.Providers.Cash.Radius = -1 }},
+		{"no currencies", func(c *Config) { c.Providers.Cash.Currencies = nil }},
+		{"currency", func(c *Config) { c.Providers.Cash.Currencies = []string{"usd"} }},
+		{"statistic", func(c *Config) { c.Providers.Cash.Statistic = "mean" }},
+		{"max age", func(c *Config) { c.Providers.Cash.MaxAge = 0 }},
+		{"premium alert", func(c *Config) { c.Providers.Crypto.PremiumAlert = -1 }},
+		{"no directions", func(c *Config) { c.Providers.Crypto.Directions = nil }},
+		{"direction", func(c *Config) { c.Providers.Crypto.Directions = []string{"cash-ruble-to-tether-in-msk"} }},
+		{"no coins", func(c *Config) { c.Providers.Coins.Currencies = nil }},
+		{"coin", func(c *Config) { c.Providers.Coins.Currencies = []string{"btc"} }},
+		{"no futures", func(c *Config) { c.Providers.Futures.Assets = nil }},
+		{"futures", func(c *Config) { c.Providers.Futures.Assets = []string{"Eu"} }},
+		{"workday max age", func(c *Config) { c.Providers.Cash.WorkdayMaxAge = -time.Hour }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Default()
+			tt.f(c)
+			assert.Error(t, c.Validate())
+		})
+	}
+}
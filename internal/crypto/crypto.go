@@ -1,62 +1,171 @@
 package crypto
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"html"
 	"log"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/ivanglie/usdrub-bot/internal/drift"
+	"github.com/ivanglie/usdrub-bot/internal/metrics"
 	"github.com/ivanglie/usdrub-bot/pkg/bestchange-go"
 )
 
 const (
 	Prefix = "1 USDT (TRC20) equals"
 	Suffix = "in Moscow, Russia by BestChange.com"
+
+	// Top is the number of listed offers.
+	Top = 5
+
+	source = "bestchange"
 )
 
 // crypto represents currency exchange crypto of cash.
 type crypto struct {
 	sync.RWMutex
-	name    string
-	f       func() (float64, error)
-	value   float64
-	err     error
-	errDate time.Time
+	name      string
+	direction bestchange.Direction
+	suffix    string // Suffix of the rate, e.g. in Moscow, Russia by BestChange.com.
+	f         func(ctx context.Context, d bestchange.Direction) (*bestchange.Offers, error)
+	value     float64
+	offers    []bestchange.Offer
+	err       error
+	errDate   time.Time
 }
 
 var (
 	RateInstance *crypto
+	instances    = map[bestchange.Direction]*crypto{}
 	lock         = &sync.Mutex{}
 )
 
-// Get returns instance of Rate.
+// Get returns instance of Rate of the default direction.
 func Get() *crypto {
+	return For(bestchange.DefaultDirection)
+}
+
+// For returns instance of Rate of direction d.
+func For(d bestchange.Direction) *crypto {
 	lock.Lock()
 	defer lock.Unlock()
 
-	if RateInstance == nil {
-		RateInstance = &crypto{name: Prefix, f: func() (float64, error) { return bestchange.NewClient().Rate() }}
+	r, ok := instances[d]
+	if !ok {
+		r = &crypto{name: Title(d), direction: d, suffix: fmt.Sprintf("in %s, Russia by BestChange.com", d.City.Title()),
+			f: func(ctx context.Context, d bestchange.Direction) (*bestchange.Offers, error) {
+				return bestchange.NewClient().WithContext(ctx).WithDirection(d).Offers()
+			}}
+		instances[d] = r
+
+		if d == bestchange.DefaultDirection {
+			RateInstance = r
+		}
 	}
 
-	return RateInstance
+	return r
+}
+
+// Title of direction d, e.g. 1 USDT (TRC20) equals.
+func Title(d bestchange.Direction) string {
+	if d.Selling() {
+		return fmt.Sprintf("1 %s sells for", d.Asset().Title())
+	}
+
+	return fmt.Sprintf("1 %s equals", d.Asset().Title())
+}
+
+// Value returns the last average rate, 0 if it's unknown.
+func (r *crypto) Value() float64 {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.value
 }
 
-// Update exchange rate of cash.
-func (r *crypto) Update() {
+// SetSuffix of the rate, e.g. in Moscow, Russia by BestChange.com.
+func (r *crypto) SetSuffix(s string) {
 	r.Lock()
 	defer r.Unlock()
 
-	v, err := r.f()
-	if v == 0 || err != nil {
+	r.suffix = s
+}
+
+// Direction of the rate.
+func (r *crypto) Direction() bestchange.Direction {
+	return r.direction
+}
+
+// Update exchange rate of cash. Offers are scraped without holding the lock.
+func (r *crypto) Update(ctx context.Context) {
+	t := time.Now()
+
+	v, err := r.f(ctx, r.direction)
+	if ctx.Err() != nil {
+		return
+	}
+
+	if errors.Is(err, bestchange.ErrNoMatch) {
+		err = drift.Get().Observe(r.source(), drift.Scrape{NoMatch: true})
+		metrics.ObserveFetch(r.source(), t, metrics.ErrDrift)
+
+		r.Lock()
+		r.err, r.errDate = err, time.Now()
+		r.Unlock()
+		return
+	}
+
+	if v == nil || err != nil {
 		log.Printf("[ERROR] %s: value=%v, error=%v", r.name, v, err)
+		metrics.ObserveFetch(r.source(), t, metrics.ErrType(err))
 
-		r.err = err
-		r.errDate = time.Now()
+		r.Lock()
+		r.err, r.errDate = err, time.Now()
+		r.Unlock()
 		return
 	}
 
+	// Average rate is a row too
+	s := drift.Scrape{Rows: v.Rows + 1, Parsed: len(v.Items)}
+	if v.Average > 0 {
+		s.Parsed++
+	}
+	drift.Get().Observe(r.source(), s)
+
+	value := average(v)
+
+	r.Lock()
 	r.err = nil
-	r.value = v
+	r.value = value
+	r.offers = v.Items
+	r.Unlock()
+
+	metrics.ObserveFetch(r.source(), t, "")
+	metrics.Rate.With(r.source(), r.direction.Asset().Code()+r.direction.Quote().Code(), "avg").Set(value)
+}
+
+// source of the rate by direction, e.g. bestchange:cash-ruble-to-tether-trc20-in-msk.
+func (r *crypto) source() string {
+	return source + ":" + r.direction.String()
+}
+
+// average rate of the page, or of the offers if it's not found.
+func average(v *bestchange.Offers) float64 {
+	if v.Average > 0 || len(v.Items) == 0 {
+		return v.Average
+	}
+
+	total := 0.0
+	for _, o := range v.Items {
+		total += o.Rate
+	}
+
+	return total / float64(len(v.Items))
 }
 
 // String representation of currency exchange cash rate.
@@ -64,5 +173,119 @@ func (r *crypto) String() string {
 	r.RLock()
 	defer r.RUnlock()
 
-	return fmt.Sprintf("%.2f RUB %s", r.value, Suffix)
+	return fmt.Sprintf("%.2f %s %s", r.value, r.direction.Quote().Code(), r.suffix)
+}
+
+// Offers represented as HTML, top ones only.
+func (r *crypto) Offers() []string {
+	r.RLock()
+	defer r.RUnlock()
+
+	s := []string{}
+	for i, o := range r.offers {
+		if i == Top {
+			break
+		}
+
+		s = append(s, offerString(r.direction, o))
+	}
+
+	return s
+}
+
+// Best returns the best offer to get amount of the currency got, within its limits,
+// with amount of the currency given for it.
+func (r *crypto) Best(amount float64) (bestchange.Offer, float64, bool) {
+	r.RLock()
+	defer r.RUnlock()
+
+	for _, o := range r.offers {
+		if o.Rate <= 0 {
+			continue
+		}
+
+		// Rate is cash per 1 of cryptocurrency
+		give := amount * o.Rate
+		if r.direction.Selling() {
+			give = amount / o.Rate
+		}
+
+		if (o.Min == 0 || give >= o.Min) && (o.Max == 0 || give <= o.Max) {
+			return o, give, true
+		}
+	}
+
+	return bestchange.Offer{}, 0, false
+}
+
+// offerString represents offer of direction d with its limits, reserve and reviews as HTML.
+func offerString(d bestchange.Direction, o bestchange.Offer) string {
+	s := fmt.Sprintf("%.2f %s: %s", o.Rate, d.Quote().Code(), html.EscapeString(o.Exchanger))
+
+	give, get := d.Give.Code(), d.Get.Code()
+
+	details := []string{}
+	switch {
+	case o.Min > 0 && o.Max > 0:
+		details = append(details, fmt.Sprintf("%s .. %s %s", amount(o.Min), amount(o.Max), give))
+	case o.Min > 0:
+		details = append(details, fmt.Sprintf("from %s %s", amount(o.Min), give))
+	case o.Max > 0:
+		details = append(details, fmt.Sprintf("up to %s %s", amount(o.Max), give))
+	}
+
+	details = append(details, fmt.Sprintf("reserve %.0f %s", o.Reserve, get), fmt.Sprintf("%d reviews", o.Reviews))
+	if o.Complaints > 0 {
+		details = append(details, fmt.Sprintf("%d complaints", o.Complaints))
+	}
+
+	for _, b := range o.Badges {
+		details = append(details, html.EscapeString(b))
+	}
+
+	return s + "\n" + strings.Join(details, ", ")
+}
+
+// amount without trailing zeros, e.g. 100000 or 0.005.
+func amount(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// Select returns the first of directions dd matching every argument:
+// buy or sell, city (e.g. spb), network (e.g. erc20), cryptocurrency or cash currency code (e.g. btc or usd).
+// It selects buying if neither buy nor sell is set.
+func Select(dd []bestchange.Direction, args []string) (bestchange.Direction, error) {
+	selling := false
+	match := []func(d bestchange.Direction) bool{}
+
+	for _, a := range args {
+		a := strings.ToLower(a)
+
+		switch {
+		case a == "buy" || a == "sell":
+			selling = a == "sell"
+		case bestchange.City(a).Supported():
+			match = append(match, func(d bestchange.Direction) bool { return d.City == bestchange.City(a) })
+		default:
+			match = append(match, func(d bestchange.Direction) bool {
+				return strings.EqualFold(d.Asset().Network(), a) || strings.EqualFold(d.Asset().Code(), a) ||
+					strings.EqualFold(d.Quote().Code(), a)
+			})
+		}
+	}
+
+	match = append(match, func(d bestchange.Direction) bool { return d.Selling() == selling })
+
+	for _, d := range dd {
+		ok := true
+		for _, m := range match {
+			ok = ok && m(d)
+		}
+
+		if ok {
+			return d, nil
+		}
+	}
+
+	return bestchange.Direction{}, fmt.Errorf("no direction matches %q", strings.Join(args, " "))
 }
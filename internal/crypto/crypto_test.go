@@ -0,0 +1,162 @@
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ivanglie/usdrub-bot/internal/metrics"
+	"github.com/ivanglie/usdrub-bot/pkg/bestchange-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_crypto_Update(t *testing.T) {
+	r := Get()
+	r.f = func(ctx context.Context, d bestchange.Direction) (*bestchange.Offers, error) {
+		o := &bestchange.Offers{Average: 96.4, Rows: 7}
+		for i := 0; i < 6; i++ {
+			o.Items = append(o.Items, bestchange.Offer{Exchanger: "e", Rate: 96 + float64(i)/10, Reserve: 1000, Reviews: 10})
+		}
+
+		return o, nil
+	}
+
+	r.Update(context.Background())
+	assert.Equal(t, 96.4, r.value)
+	assert.Len(t, r.offers, 6)
+	assert.Len(t, r.Offers(), Top)
+	assert.Equal(t, "96.40 RUB in Moscow, Russia by BestChange.com", r.String())
+
+	// Fetches are observed by direction
+	b := &bytes.Buffer{}
+	metrics.Write(b)
+	assert.Contains(t, b.String(), `usdrub_last_update_timestamp_seconds{source="bestchange:cash-ruble-to-tether-trc20-in-msk"}`)
+
+	// Error keeps the previous offers
+	r.f = func(ctx context.Context, d bestchange.Direction) (*bestchange.Offers, error) {
+		return nil, errors.New("error")
+	}
+
+	r.Update(context.Background())
+	assert.Len(t, r.offers, 6)
+	assert.Error(t, r.err)
+}
+
+func Test_crypto_Update_unlocked(t *testing.T) {
+	r := &crypto{name: "test", direction: bestchange.DefaultDirection}
+	r.f = func(ctx context.Context, d bestchange.Direction) (*bestchange.Offers, error) {
+		// Rate is available while scraping
+		assert.Equal(t, 0.0, r.Value())
+		return &bestchange.Offers{Average: 96.4, Rows: 1, Items: []bestchange.Offer{{Exchanger: "e", Rate: 96.4}}}, nil
+	}
+
+	r.Update(context.Background())
+	assert.Equal(t, 96.4, r.Value())
+}
+
+func Test_average(t *testing.T) {
+	assert.Equal(t, 96.4, average(&bestchange.Offers{Average: 96.4, Items: []bestchange.Offer{{Rate: 90}}}))
+	assert.Equal(t, 95.0, average(&bestchange.Offers{Items: []bestchange.Offer{{Rate: 94}, {Rate: 96}}}))
+	assert.Equal(t, 0.0, average(&bestchange.Offers{}))
+}
+
+func Test_crypto_Best(t *testing.T) {
+	r := &crypto{direction: bestchange.DefaultDirection, offers: []bestchange.Offer{
+		{Exchanger: "A", Rate: 95, Min: 500000},
+		{Exchanger: "B", Rate: 96, Max: 100000},
+		{Exchanger: "C", Rate: 97},
+	}}
+
+	o, give, ok := r.Best(1000)
+	assert.True(t, ok)
+	assert.Equal(t, "B", o.Exchanger)
+	assert.Equal(t, 96000.0, give)
+
+	o, _, _ = r.Best(2000)
+	assert.Equal(t, "C", o.Exchanger)
+
+	// Selling
+	r = &crypto{direction: bestchange.Direction{Give: bestchange.USDTTRC20, Get: bestchange.CashUSD, City: bestchange.Moscow},
+		offers: []bestchange.Offer{{Exchanger: "D", Rate: 0.98, Max: 1000}}}
+
+	o, give, ok = r.Best(490)
+	assert.True(t, ok)
+	assert.Equal(t, "D", o.Exchanger)
+	assert.InDelta(t, 500.0, give, 1e-9)
+
+	_, _, ok = r.Best(5000)
+	assert.False(t, ok)
+}
+
+func Test_offerString(t *testing.T) {
+	d := bestchange.DefaultDirection
+	o := bestchange.Offer{Exchanger: "A&B", Rate: 95.8, Reserve: 1234567.89, Min: 100000, Max: 3000000,
+		Reviews: 2345, Complaints: 1, Badges: []string{"Manual"}}
+	assert.Equal(t, "95.80 RUB: A&amp;B\n100000 .. 3000000 RUB, reserve 1234568 USDT, 2345 reviews, 1 complaints, Manual",
+		offerString(d, o))
+
+	o = bestchange.Offer{Exchanger: "C", Rate: 96, Reserve: 10, Min: 500}
+	assert.Equal(t, "96.00 RUB: C\nfrom 500 RUB, reserve 10 USDT, 0 reviews", offerString(d, o))
+
+	// Selling
+	d = bestchange.Direction{Give: bestchange.USDTTRC20, Get: bestchange.CashRUB, City: bestchange.Moscow}
+	o = bestchange.Offer{Exchanger: "D", Rate: 94.5, Reserve: 5000000, Max: 20000.5}
+	assert.Equal(t, "94.50 RUB: D\nup to 20000.5 USDT, reserve 5000000 RUB, 0 reviews", offerString(d, o))
+}
+
+func TestFor(t *testing.T) {
+	assert.Equal(t, Get(), For(bestchange.DefaultDirection))
+	assert.Equal(t, RateInstance, Get())
+	assert.Equal(t, Prefix, Get().name)
+
+	d := bestchange.Direction{Give: bestchange.USDTERC20, Get: bestchange.CashRUB, City: bestchange.SaintPetersburg}
+	r := For(d)
+	assert.NotEqual(t, Get(), r)
+	assert.Equal(t, d, r.Direction())
+	assert.Equal(t, "1 USDT (ERC20) sells for", r.name)
+	assert.Equal(t, "bestchange:tether-erc20-to-cash-ruble-in-spb", r.source())
+	assert.Equal(t, "0.00 RUB in Saint Petersburg, Russia by BestChange.com", r.String())
+
+	r.SetSuffix("by BestChange")
+	assert.Equal(t, "0.00 RUB by BestChange", r.String())
+}
+
+func TestSelect(t *testing.T) {
+	dd := []bestchange.Direction{
+		bestchange.DefaultDirection,
+		{Give: bestchange.CashRUB, Get: bestchange.USDTERC20, City: bestchange.Moscow},
+		{Give: bestchange.USDTTRC20, Get: bestchange.CashRUB, City: bestchange.Moscow},
+		{Give: bestchange.USDTTRC20, Get: bestchange.CashRUB, City: bestchange.SaintPetersburg},
+		{Give: bestchange.CashUSD, Get: bestchange.USDTTRC20, City: bestchange.Moscow},
+		{Give: bestchange.CashRUB, Get: bestchange.BTC, City: bestchange.Moscow},
+	}
+
+	tests := []struct {
+		args    []string
+		want    int
+		wantErr bool
+	}{
+		{nil, 0, false},
+		{[]string{"buy"}, 0, false},
+		{[]string{"erc20"}, 1, false},
+		{[]string{"sell"}, 2, false},
+		{[]string{"sell", "spb"}, 3, false},
+		{[]string{"SPB", "Sell"}, 3, false},
+		{[]string{"usd"}, 4, false},
+		{[]string{"btc"}, 5, false},
+		{[]string{"spb"}, 0, true},
+		{[]string{"bep20"}, 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := Select(dd, tt.args)
+		if tt.wantErr {
+			assert.Error(t, err, tt.args)
+			continue
+		}
+
+		assert.NoError(t, err, tt.args)
+		assert.Equal(t, dd[tt.want], got, tt.args)
+	}
+}
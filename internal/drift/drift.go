@@ -0,0 +1,162 @@
+package drift
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/ivanglie/usdrub-bot/internal/metrics"
+)
+
+const (
+	// MinHitRatio is the minimum share of parsed rows of a scrape.
+	MinHitRatio = 0.5
+	// MinRowsRatio is the minimum number of rows of a scrape relative to the expected one.
+	MinRowsRatio = 0.5
+
+	// alpha is smoothing factor of the expected number of rows.
+	alpha = 0.2
+)
+
+// Scrape of a page.
+type Scrape struct {
+	Rows    int  // Rows found on the page by selectors.
+	Parsed  int  // Rows parsed of them.
+	NoMatch bool // Page loaded, but nothing matched.
+}
+
+// Error of layout drift.
+type Error struct {
+	Source string
+	Reason string
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: layout drift: %s", e.Source, e.Reason)
+}
+
+// state of a source.
+type state struct {
+	expected float64 // Smoothed number of rows of successful scrapes.
+	drift    *Error
+	alerted  bool
+}
+
+// detector of layout drift of scraped pages.
+type detector struct {
+	sync.Mutex
+	notify  func(text string)
+	sources map[string]*state
+}
+
+var (
+	detectorInstance *detector
+	lock             = &sync.Mutex{}
+)
+
+// Get returns instance of the detector.
+func Get() *detector {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if detectorInstance == nil {
+		detectorInstance = &detector{sources: map[string]*state{}}
+	}
+
+	return detectorInstance
+}
+
+// SetNotifier sets function to notify of drift and recovery, e.g. to send message to admins.
+func (d *detector) SetNotifier(f func(text string)) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.notify = f
+}
+
+// Observe scrape s of source, and return error if it drifted.
+// Rows found are expected to be at least MinRowsRatio of the usual number, and parsed rows
+// at least MinHitRatio of them. Notifier is called once on drift and once on recovery, outside of the lock.
+func (d *detector) Observe(source string, s Scrape) error {
+	d.Lock()
+
+	st, ok := d.sources[source]
+	if !ok {
+		st = &state{}
+		d.sources[source] = st
+	}
+
+	// Rows are unknown
+	if s.Rows < s.Parsed {
+		s.Rows = s.Parsed
+	}
+
+	ratio := 0.0
+	if s.Rows > 0 {
+		ratio = float64(s.Parsed) / float64(s.Rows)
+	}
+
+	metrics.ScrapeRows.With(source).Set(float64(s.Rows))
+	metrics.ScrapeHitRatio.With(source).Set(ratio)
+
+	var err *Error
+	switch {
+	case s.NoMatch || s.Parsed == 0:
+		err = &Error{source, fmt.Sprintf("page loaded, but nothing matched (%d rows)", s.Rows)}
+	case ratio < MinHitRatio:
+		err = &Error{source, fmt.Sprintf("%d of %d rows parsed", s.Parsed, s.Rows)}
+	case st.expected > 0 && float64(s.Rows) < MinRowsRatio*st.expected:
+		err = &Error{source, fmt.Sprintf("%d rows found, %.0f expected", s.Rows, st.expected)}
+	}
+
+	if err == nil {
+		if st.expected == 0 {
+			st.expected = float64(s.Rows)
+		} else {
+			st.expected = alpha*float64(s.Rows) + (1-alpha)*st.expected
+		}
+	}
+
+	text := d.transition(st, source, err, s)
+	notify := d.notify
+	d.Unlock()
+
+	if len(text) > 0 && notify != nil {
+		notify(text)
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	return err
+}
+
+// transition of the source state, returning notification of drift and recovery, if any.
+func (d *detector) transition(st *state, source string, err *Error, s Scrape) (text string) {
+	switch {
+	case err != nil:
+		metrics.Drift.With(source).Set(1)
+		log.Printf("[WARNING] %v", err)
+
+		st.drift = err
+		if !st.alerted && d.notify != nil {
+			text = fmt.Sprintf("⚠️ %v", err)
+			st.alerted = true
+		}
+	case st.drift != nil:
+		metrics.Drift.With(source).Set(0)
+		log.Printf("[INFO] %s: layout drift is recovered, %d of %d rows parsed", source, s.Parsed, s.Rows)
+
+		if st.alerted && d.notify != nil {
+			text = fmt.Sprintf("✅ %s: layout drift is recovered, %d of %d rows parsed", source, s.Parsed, s.Rows)
+		}
+
+		st.drift, st.alerted = nil, false
+	default:
+		metrics.Drift.With(source).Set(0)
+	}
+
+	return
+}
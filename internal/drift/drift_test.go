@@ -0,0 +1,75 @@
+package drift
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetector_Observe(t *testing.T) {
+	d := &detector{sources: map[string]*state{}}
+
+	var alerts []string
+	d.SetNotifier(func(text string) { alerts = append(alerts, text) })
+
+	assert.NoError(t, d.Observe("s", Scrape{Rows: 10, Parsed: 9}))
+	assert.Equal(t, 10.0, d.sources["s"].expected)
+
+	// Nothing matched
+	err := d.Observe("s", Scrape{NoMatch: true})
+	var e *Error
+	assert.True(t, errors.As(err, &e))
+	assert.Contains(t, err.Error(), "nothing matched")
+	assert.Equal(t, 1, len(alerts))
+
+	// Alerted once
+	assert.Error(t, d.Observe("s", Scrape{Rows: 10}))
+	assert.Equal(t, 1, len(alerts))
+
+	// Recovered
+	assert.NoError(t, d.Observe("s", Scrape{Rows: 10, Parsed: 10}))
+	assert.Equal(t, 2, len(alerts))
+	assert.Contains(t, alerts[1], "recovered")
+
+	// Low hit ratio
+	assert.ErrorContains(t, d.Observe("s", Scrape{Rows: 10, Parsed: 4}), "4 of 10 rows parsed")
+
+	// Row count dropped
+	assert.ErrorContains(t, d.Observe("s", Scrape{Rows: 3, Parsed: 3}), "3 rows found, 10 expected")
+	assert.Equal(t, 3, len(alerts))
+}
+
+func TestDetector_Observe_withoutNotifier(t *testing.T) {
+	d := &detector{sources: map[string]*state{}}
+
+	assert.Error(t, d.Observe("s", Scrape{NoMatch: true}))
+	assert.False(t, d.sources["s"].alerted)
+
+	// Alerted as soon as notifier is set
+	var alerts []string
+	d.SetNotifier(func(text string) { alerts = append(alerts, text) })
+
+	assert.Error(t, d.Observe("s", Scrape{NoMatch: true}))
+	assert.Equal(t, 1, len(alerts))
+}
+
+func TestDetector_Observe_notifyUnlocked(t *testing.T) {
+	d := &detector{sources: map[string]*state{}}
+
+	// Notifier doesn't block the detector
+	var rows int
+	d.SetNotifier(func(text string) {
+		d.Lock()
+		rows = int(d.sources["s"].expected)
+		d.Unlock()
+	})
+
+	assert.NoError(t, d.Observe("s", Scrape{Rows: 10, Parsed: 10}))
+	assert.Error(t, d.Observe("s", Scrape{NoMatch: true}))
+	assert.Equal(t, 10, rows)
+}
+
+func TestGet(t *testing.T) {
+	assert.Equal(t, Get(), Get())
+}
@@ -0,0 +1,46 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+)
+
+// Quote returns rate of currency pair from/to.
+type Quote func(ctx context.Context, from, to string) (float64, error)
+
+// Cross returns synthetic quote of pair from/to through currency via,
+// e.g. USD/RUB as USD/CNY by first times CNY/RUB by second.
+func Cross(via string, first, second Quote) Quote {
+	return func(ctx context.Context, from, to string) (float64, error) {
+		a, err := first(ctx, from, via)
+		if err != nil {
+			return 0, fmt.Errorf("%s/%s: %w", from, via, err)
+		}
+
+		if a == 0 {
+			return 0, fmt.Errorf("%s/%s: no rate", from, via)
+		}
+
+		b, err := second(ctx, via, to)
+		if err != nil {
+			return 0, fmt.Errorf("%s/%s: %w", via, to, err)
+		}
+
+		if b == 0 {
+			return 0, fmt.Errorf("%s/%s: no rate", via, to)
+		}
+
+		return a * b, nil
+	}
+}
+
+// Either returns quote q of pairs it quotes directly, and quote of cross c of other pairs.
+func Either(direct func(from, to string) bool, q, c Quote) Quote {
+	return func(ctx context.Context, from, to string) (float64, error) {
+		if direct(from, to) {
+			return q(ctx, from, to)
+		}
+
+		return c(ctx, from, to)
+	}
+}
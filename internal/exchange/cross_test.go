@@ -0,0 +1,48 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCross(t *testing.T) {
+	first := func(ctx context.Context, from, to string) (float64, error) {
+		assert.Equal(t, "USD", from)
+		assert.Equal(t, "CNY", to)
+		return 7.25, nil
+	}
+
+	second := func(ctx context.Context, from, to string) (float64, error) {
+		assert.Equal(t, "CNY", from)
+		assert.Equal(t, "RUB", to)
+		return 12.5, nil
+	}
+
+	v, err := Cross("CNY", first, second)(context.Background(), "USD", "RUB")
+	assert.NoError(t, err)
+	assert.Equal(t, 90.625, v)
+
+	// Errors
+	errFirst := func(ctx context.Context, from, to string) (float64, error) { return 0, errors.New("error") }
+	_, err = Cross("CNY", errFirst, second)(context.Background(), "USD", "RUB")
+	assert.ErrorContains(t, err, "USD/CNY: error")
+
+	zero := func(ctx context.Context, from, to string) (float64, error) { return 0, nil }
+	_, err = Cross("CNY", first, zero)(context.Background(), "USD", "RUB")
+	assert.ErrorContains(t, err, "CNY/RUB: no rate")
+}
+
+func TestEither(t *testing.T) {
+	direct := func(ctx context.Context, from, to string) (float64, error) { return 12.5, nil }
+	cross := func(ctx context.Context, from, to string) (float64, error) { return 90.6, nil }
+	q := Either(MOEXDirect, direct, cross)
+
+	v, _ := q(context.Background(), "CNY", "RUB")
+	assert.Equal(t, 12.5, v)
+
+	v, _ = q(context.Background(), "USD", "RUB")
+	assert.Equal(t, 90.6, v)
+}
@@ -1,11 +1,15 @@
 package exchange
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/ivanglie/usdrub-bot/internal/httputil"
+	"github.com/ivanglie/usdrub-bot/internal/metrics"
 	"github.com/ivanglie/usdrub-bot/pkg/cbr-go"
 	"github.com/ivanglie/usdrub-bot/pkg/coingate-go"
 	"github.com/ivanglie/usdrub-bot/pkg/moex-go"
@@ -23,20 +27,39 @@ const (
 type exchange struct {
 	sync.RWMutex
 	name    string
-	f       func() (float64, error)
+	source  string
+	from    string
+	to      string
+	enabled bool
+	check   func(from, to string) error
+	direct  func(from, to string) bool // Pairs quoted directly, others are synthetic cross rates via currency via.
+	via     string
+	f       func(ctx context.Context, from, to string) (float64, error)
 	value   float64
+	updated time.Time
 	err     error
 	errDate time.Time
 }
 
 // update exchange rate.
-func (r *exchange) update() {
+func (r *exchange) update(ctx context.Context) {
 	r.Lock()
 	defer r.Unlock()
 
-	v, err := r.f()
+	if !r.enabled {
+		return
+	}
+
+	t := time.Now()
+
+	v, err := r.f(ctx, r.from, r.to)
+	if ctx.Err() != nil {
+		return
+	}
+
 	if err != nil || v == 0 {
 		log.Printf("[ERROR] %s: value=%f, error=%v", r.name, v, err)
+		metrics.ObserveFetch(r.source, t, metrics.ErrType(err))
 
 		r.err = err
 		r.errDate = time.Now()
@@ -44,7 +67,53 @@ func (r *exchange) update() {
 	}
 
 	r.value = v
+	r.updated = time.Now()
 	r.err = nil
+
+	kind := "last"
+	if r.synthetic() {
+		kind = "synthetic"
+	}
+
+	metrics.ObserveFetch(r.source, t, "")
+	metrics.Rate.With(r.source, r.from+r.to, kind).Set(v)
+}
+
+// synthetic reports whether rate is a cross rate, which r must be locked for.
+func (r *exchange) synthetic() bool {
+	return r.direct != nil && !r.direct(r.from, r.to)
+}
+
+// Synthetic reports whether rate is a cross rate via another currency, not a direct quote.
+func (r *exchange) Synthetic() bool {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.synthetic()
+}
+
+// Rate returns the last value of rate, 0 if it's unknown.
+func (r *exchange) Rate() float64 {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.value
+}
+
+// Pair returns currency pair of rate, e.g. USD/RUB.
+func (r *exchange) Pair() string {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.from + "/" + r.to
+}
+
+// Updated returns time of the last successful update.
+func (r *exchange) Updated() time.Time {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.updated
 }
 
 // String representation of rate.
@@ -52,7 +121,19 @@ func (r *exchange) String() string {
 	r.RLock()
 	defer r.RUnlock()
 
-	return fmt.Sprintf("%.2f RUB by %s", r.value, r.name)
+	if r.synthetic() {
+		return fmt.Sprintf("%.2f %s by %s (synthetic via %s)", r.value, r.to, r.name, r.via)
+	}
+
+	return fmt.Sprintf("%.2f %s by %s", r.value, r.to, r.name)
+}
+
+// Enabled reports whether exchange rate is enabled.
+func (r *exchange) Enabled() bool {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.enabled
 }
 
 // rates represents exchange rates.
@@ -74,21 +155,75 @@ func Get() *rates {
 	if ratesInstance == nil {
 		ratesInstance = &rates{}
 		ratesInstance.values = []*exchange{
-			{name: Forex, f: func() (float64, error) { return coingate.NewClient().GetRate("USD", "RUB") }},
-			{name: MOEX, f: func() (float64, error) { return moex.NewClient().GetRate(moex.USDRUB) }},
-			{name: CBRF, f: func() (float64, error) { return cbr.NewClient().GetRate("USD", time.Now()) }}}
+			{name: Forex, source: "coingate", from: "USD", to: "RUB", enabled: true, f: forexQuote},
+			{name: MOEX, source: "moex", from: "USD", to: "RUB", enabled: true,
+				check: func(from, to string) error {
+					if to != "RUB" {
+						return fmt.Errorf("unsupported pair: %s/%s", from, to)
+					}
+					return nil
+				},
+				direct: MOEXDirect, via: "CNY",
+				f: MOEXQuote},
+			{name: CBRF, source: "cbr", from: "USD", to: "RUB", enabled: true,
+				check: func(from, to string) error {
+					if to != "RUB" {
+						return fmt.Errorf("unsupported pair: %s/%s", from, to)
+					}
+					return nil
+				},
+				f: func(ctx context.Context, from, to string) (float64, error) {
+					return cbr.NewClient().WithContext(ctx).GetRate(from, time.Now())
+				}}}
 	}
 
 	return ratesInstance
 }
 
+// Configure enables or disables exchange rate by name and sets its currency pair, e.g. USD/RUB.
+func (r *rates) Configure(name string, enabled bool, pair string) error {
+	v := r.Value(name)
+	if v == nil {
+		return fmt.Errorf("unknown exchange rate: %s", name)
+	}
+
+	from, to, ok := strings.Cut(pair, "/")
+	if !ok || len(from) == 0 || len(to) == 0 {
+		return fmt.Errorf("%s: invalid pair %q", name, pair)
+	}
+
+	if v.check != nil {
+		if err := v.check(from, to); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+
+	v.Lock()
+	defer v.Unlock()
+
+	if v.from != from || v.to != to {
+		v.from, v.to, v.value, v.updated = from, to, 0, time.Time{}
+	}
+
+	v.enabled = enabled
+
+	return nil
+}
+
 // Update exchange rates.
-func (r *rates) Update() {
+func (r *rates) Update(ctx context.Context) {
 	r.Lock()
 	defer r.Unlock()
 
 	for _, v := range r.values {
-		v.update()
+		v.update(ctx)
+	}
+}
+
+// UpdateValue updates exchange rate by name.
+func (r *rates) UpdateValue(ctx context.Context, name string) {
+	if v := r.Value(name); v != nil {
+		v.update(ctx)
 	}
 }
 
@@ -113,8 +248,51 @@ func (r *rates) String() string {
 
 	var s string
 	for _, v := range r.values {
-		s += fmt.Sprintf("%s\n", v)
+		if v.Enabled() {
+			s += fmt.Sprintf("%s\n", v)
+		}
 	}
 
 	return s
 }
+
+// moexCodes of MOEX securities by currency pair.
+// USD and EUR stopped trading in 2024, so their rates are synthetic.
+var moexCodes = map[string]string{
+	"GBP/RUB": moex.GBPRUB,
+	"CNY/RUB": moex.CNYRUB,
+}
+
+// MOEXQuote of pair from/to on MOEX, or synthetic one via CNY if it isn't traded.
+var MOEXQuote = Either(MOEXDirect, moexQuote, Cross("CNY", forexQuote, moexQuote))
+
+// MOEXWAPrice of pair from/to on MOEX, which is the weighted average price of the day,
+// or synthetic one via CNY if it isn't traded.
+var MOEXWAPrice = Either(MOEXDirect, moexWAPrice, Cross("CNY", forexQuote, moexWAPrice))
+
+// MOEXDirect reports whether pair from/to is traded on MOEX.
+func MOEXDirect(from, to string) bool {
+	_, ok := moexCodes[from+"/"+to]
+	return ok
+}
+
+// moexQuote of pair from/to traded on MOEX.
+func moexQuote(ctx context.Context, from, to string) (float64, error) {
+	c := moex.NewClient()
+	c.SetFetchFunction(httputil.Get(ctx))
+	return c.GetRate(moexCodes[from+"/"+to])
+}
+
+// moexWAPrice of pair from/to traded on MOEX.
+func moexWAPrice(ctx context.Context, from, to string) (float64, error) {
+	c := moex.NewClient()
+	c.SetFetchFunction(httputil.Get(ctx))
+	return c.GetWAPrice(moexCodes[from+"/"+to])
+}
+
+// forexQuote of any pair by CoinGate.
+func forexQuote(ctx context.Context, from, to string) (float64, error) {
+	c := coingate.NewClient()
+	c.SetFetchFunction(httputil.Get(ctx))
+	return c.GetRate(from, to)
+}
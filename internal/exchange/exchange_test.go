@@ -1,23 +1,35 @@
 package exchange
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func Test_rate_Update(t *testing.T) {
 	r := Get()
-	r.Value(Forex).f = func() (float64, error) { return 50.0, nil }
+	r.Value(Forex).f = func(ctx context.Context, from, to string) (float64, error) { return 50.0, nil }
 
-	r.Update()
+	r.Update(context.Background())
 	assert.Equal(t, 50.0, r.Value(Forex).value)
+	assert.WithinDuration(t, time.Now(), r.Value(Forex).Updated(), time.Second)
 
 	// Error
-	r.Value(Forex).f = func() (float64, error) { return 51.0, errors.New("error") }
+	r.Value(Forex).f = func(ctx context.Context, from, to string) (float64, error) { return 51.0, errors.New("error") }
 
-	r.Update()
+	r.Update(context.Background())
+	assert.Equal(t, 50.0, r.Value(Forex).value)
+
+	// Canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r.Value(Forex).f = func(ctx context.Context, from, to string) (float64, error) { return 0, ctx.Err() }
+
+	r.Update(ctx)
 	assert.Equal(t, 50.0, r.Value(Forex).value)
 }
 
@@ -29,7 +41,7 @@ func Test_rate_String(t *testing.T) {
 
 	t.Log(r)
 
-	assert.Equal(t, "50.00 RUB by Forex\n51.00 RUB by Moscow Exchange\n52.00 RUB by Russian Central Bank\n", r.String())
+	assert.Equal(t, "50.00 RUB by Forex\n51.00 RUB by Moscow Exchange (synthetic via CNY)\n52.00 RUB by Russian Central Bank\n", r.String())
 }
 
 func Test_rates_Value(t *testing.T) {
@@ -37,7 +49,52 @@ func Test_rates_Value(t *testing.T) {
 	r.Value(Forex).value = 50.0
 
 	assert.Equal(t, 50.0, r.Value(Forex).value)
+	assert.Equal(t, 50.0, r.Value(Forex).Rate())
+	assert.Equal(t, "USD/RUB", r.Value(Forex).Pair())
 
 	// Error
 	assert.Nil(t, r.Value("Phorex"))
 }
+
+func Test_rates_UpdateValue(t *testing.T) {
+	r := Get()
+	r.Value(MOEX).f = func(ctx context.Context, from, to string) (float64, error) { return 60.0, nil }
+
+	r.UpdateValue(context.Background(), MOEX)
+	assert.Equal(t, 60.0, r.Value(MOEX).value)
+
+	// Unknown name
+	r.UpdateValue(context.Background(), "Phorex")
+}
+
+func Test_rates_Configure(t *testing.T) {
+	r := Get()
+	r.Value(MOEX).f = func(ctx context.Context, from, to string) (float64, error) {
+		assert.Equal(t, "CNY", from)
+		assert.Equal(t, "RUB", to)
+		return 12.5, nil
+	}
+
+	assert.NoError(t, r.Configure(MOEX, true, "CNY/RUB"))
+	assert.Equal(t, 0.0, r.Value(MOEX).value)
+
+	r.UpdateValue(context.Background(), MOEX)
+	assert.Equal(t, "12.50 RUB by Moscow Exchange", r.Value(MOEX).String())
+	assert.False(t, r.Value(MOEX).Synthetic())
+
+	// Disabled
+	assert.NoError(t, r.Configure(CBRF, false, "USD/RUB"))
+	assert.False(t, r.Value(CBRF).Enabled())
+	assert.NotContains(t, r.String(), CBRF)
+
+	assert.NoError(t, r.Configure(MOEX, true, "USD/RUB"))
+	assert.True(t, r.Value(MOEX).Synthetic())
+	assert.NoError(t, r.Configure(CBRF, true, "USD/RUB"))
+	assert.False(t, r.Value(CBRF).Synthetic())
+
+	// Errors
+	assert.Error(t, r.Configure("Phorex", true, "USD/RUB"))
+	assert.Error(t, r.Configure(Forex, true, "USDRUB"))
+	assert.Error(t, r.Configure(MOEX, true, "USD/EUR"))
+	assert.Error(t, r.Configure(CBRF, true, "USD/EUR"))
+}
@@ -0,0 +1,193 @@
+package fixing
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ivanglie/usdrub-bot/internal/history"
+	"github.com/ivanglie/usdrub-bot/internal/httputil"
+	"github.com/ivanglie/usdrub-bot/internal/metrics"
+	"github.com/ivanglie/usdrub-bot/pkg/moex-go"
+)
+
+const source = "moex_fixing"
+
+// codes of MOEX fixings by currency pair.
+var codes = map[string]string{
+	"USD/RUB": moex.USDFIX,
+	"EUR/RUB": moex.EURFIX,
+	"CNY/RUB": moex.CNYFIX,
+}
+
+// store of history series.
+type store interface {
+	Add(name string, t time.Time, v float64)
+}
+
+// fixing represents the latest MOEX fixing and indicative rate of currency pair.
+type fixing struct {
+	sync.RWMutex
+	pair       string
+	history    store
+	f          func(ctx context.Context, code string) (moex.Fixing, error)
+	indicative func(ctx context.Context, from, to string) (moex.Fixing, error)
+	fixing     moex.Fixing
+	rate       moex.Fixing
+	err        error
+	errDate    time.Time
+}
+
+var (
+	RateInstance *fixing
+	lock         = &sync.Mutex{}
+)
+
+// Get returns instance of fixing of USD/RUB.
+func Get() *fixing {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if RateInstance == nil {
+		RateInstance = &fixing{pair: "USD/RUB", history: history.Get(),
+			f: func(ctx context.Context, code string) (moex.Fixing, error) {
+				c := moex.NewClient()
+				c.SetFetchFunction(httputil.Get(ctx))
+				return c.GetFixing(code)
+			},
+			indicative: func(ctx context.Context, from, to string) (moex.Fixing, error) {
+				c := moex.NewClient()
+				c.SetFetchFunction(httputil.Get(ctx))
+				return c.GetIndicativeRate(from, to)
+			}}
+	}
+
+	return RateInstance
+}
+
+// Code of fixing of currency pair, e.g. USDFIXME of USD/RUB, or empty one if there's no fixing.
+func Code(pair string) string {
+	return codes[pair]
+}
+
+// Series returns name of history series of fixing code, e.g. fixing:USDFIXME.
+func Series(code string) string {
+	return "fixing:" + code
+}
+
+// Configure currency pair, e.g. CNY/RUB.
+func (r *fixing) Configure(pair string) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.pair != pair {
+		r.pair, r.fixing, r.rate = pair, moex.Fixing{}, moex.Fixing{}
+	}
+}
+
+// Update the latest fixing and indicative rate, recording new fixings to history.
+// Rates are fetched without holding the lock.
+func (r *fixing) Update(ctx context.Context) {
+	r.RLock()
+	pair := r.pair
+	r.RUnlock()
+
+	from, to, _ := strings.Cut(pair, "/")
+	t := time.Now()
+
+	var err error
+	var fix moex.Fixing
+	code := Code(pair)
+	if len(code) > 0 {
+		fix, err = r.f(ctx, code)
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	v, indErr := r.indicative(ctx, from, to)
+	if ctx.Err() != nil {
+		return
+	}
+
+	r.Lock()
+
+	// Pair is reconfigured meanwhile
+	if r.pair != pair {
+		r.Unlock()
+		return
+	}
+
+	fixed, record := len(code) > 0 && err == nil, false
+	if fixed {
+		record = fix.Time.After(r.fixing.Time)
+		r.fixing = fix
+	}
+
+	if indErr == nil {
+		r.rate = v
+	}
+
+	if err == nil {
+		err = indErr
+	}
+
+	if err != nil {
+		r.err, r.errDate = err, time.Now()
+	} else {
+		r.err = nil
+	}
+
+	r.Unlock()
+
+	if record {
+		r.history.Add(Series(code), fix.Time, fix.Value)
+	}
+
+	if fixed {
+		metrics.Rate.With(source, from+to, "fixing").Set(fix.Value)
+	}
+
+	if indErr == nil {
+		metrics.Rate.With(source, from+to, "indicative").Set(v.Value)
+	}
+
+	if err != nil {
+		log.Printf("[ERROR] MOEX fixing of %s: error=%v", pair, err)
+		metrics.ObserveFetch(source, t, metrics.ErrType(err))
+		return
+	}
+
+	metrics.ObserveFetch(source, t, "")
+}
+
+// Fixing returns the latest fixing, if it's known.
+func (r *fixing) Fixing() (moex.Fixing, bool) {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.fixing, r.fixing.Value > 0
+}
+
+// String representation of the latest fixing and indicative rate, or empty one if both are unknown.
+func (r *fixing) String() string {
+	r.RLock()
+	defer r.RUnlock()
+
+	_, to, _ := strings.Cut(r.pair, "/")
+
+	s := []string{}
+	if r.fixing.Value > 0 {
+		s = append(s, fmt.Sprintf("Fixing:\t%.2f %s at %s (%s)", r.fixing.Value, to, r.fixing.Time.Format("15:04 02.01.2006"), r.fixing.Code))
+	}
+
+	if r.rate.Value > 0 {
+		s = append(s, fmt.Sprintf("Indicative:\t%.2f %s at %s", r.rate.Value, to, r.rate.Time.Format("15:04 02.01.2006")))
+	}
+
+	return strings.Join(s, "\n")
+}
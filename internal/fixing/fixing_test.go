@@ -0,0 +1,88 @@
+package fixing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ivanglie/usdrub-bot/internal/history"
+	"github.com/ivanglie/usdrub-bot/pkg/moex-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_fixing_Update(t *testing.T) {
+	at := time.Date(2024, 6, 13, 15, 30, 0, 0, moex.Moscow)
+
+	h := history.New()
+	r := &fixing{pair: "USD/RUB", history: h,
+		f: func(ctx context.Context, code string) (moex.Fixing, error) {
+			assert.Equal(t, moex.USDFIX, code)
+			return moex.Fixing{Code: code, Time: at, Value: 88.7}, nil
+		},
+		indicative: func(ctx context.Context, from, to string) (moex.Fixing, error) {
+			assert.Equal(t, "USD", from)
+			assert.Equal(t, "RUB", to)
+			return moex.Fixing{Code: "USD/RUB", Time: at.Add(3 * time.Hour), Value: 88.95}, nil
+		}}
+
+	r.Update(context.Background())
+	assert.NoError(t, r.err)
+
+	f, ok := r.Fixing()
+	assert.True(t, ok)
+	assert.Equal(t, 88.7, f.Value)
+	assert.Equal(t, "Fixing:\t88.70 RUB at 15:30 13.06.2024 (USDFIXME)\nIndicative:\t88.95 RUB at 18:30 13.06.2024", r.String())
+
+	// Recorded once
+	r.Update(context.Background())
+	assert.Len(t, h.Series(Series(moex.USDFIX), time.Time{}), 1)
+
+	// Error keeps the previous fixing
+	r.indicative = func(ctx context.Context, from, to string) (moex.Fixing, error) {
+		return moex.Fixing{}, errors.New("error")
+	}
+
+	r.Update(context.Background())
+	assert.Error(t, r.err)
+	assert.Contains(t, r.String(), "Fixing:\t88.70 RUB")
+
+	// Pair without fixing
+	r.Configure("GBP/RUB")
+	r.Update(context.Background())
+	_, ok = r.Fixing()
+	assert.False(t, ok)
+	assert.Empty(t, r.String())
+}
+
+func Test_fixing_Update_unlocked(t *testing.T) {
+	h := history.New()
+	r := &fixing{pair: "USD/RUB", history: h}
+	r.f = func(ctx context.Context, code string) (moex.Fixing, error) {
+		// Fixing is available while fetching
+		_, ok := r.Fixing()
+		assert.False(t, ok)
+
+		// Pair is changed while fetching
+		r.Configure("EUR/RUB")
+
+		return moex.Fixing{Code: code, Time: time.Now(), Value: 88.7}, nil
+	}
+	r.indicative = func(ctx context.Context, from, to string) (moex.Fixing, error) {
+		return moex.Fixing{Time: time.Now(), Value: 88.95}, nil
+	}
+
+	r.Update(context.Background())
+	assert.Empty(t, r.String())
+	assert.Empty(t, h.Series(Series(moex.USDFIX), time.Time{}))
+}
+
+func TestGet(t *testing.T) {
+	assert.Equal(t, Get(), Get())
+}
+
+func TestCode(t *testing.T) {
+	assert.Equal(t, "CNYFIX", Code("CNY/RUB"))
+	assert.Empty(t, Code("GBP/RUB"))
+	assert.Equal(t, "fixing:CNYFIX", Series("CNYFIX"))
+}
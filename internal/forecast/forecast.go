@@ -0,0 +1,267 @@
+package forecast
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ivanglie/usdrub-bot/internal/exchange"
+	"github.com/ivanglie/usdrub-bot/internal/fixing"
+	"github.com/ivanglie/usdrub-bot/internal/history"
+	"github.com/ivanglie/usdrub-bot/internal/metrics"
+	"github.com/ivanglie/usdrub-bot/internal/scheduler"
+	"github.com/ivanglie/usdrub-bot/pkg/cbr-go"
+	"github.com/ivanglie/usdrub-bot/pkg/moex-go"
+)
+
+const source = "cbrf_forecast"
+
+// Estimate of the official rate of date.
+type Estimate struct {
+	Date   time.Time // Date the rate is set for.
+	Value  float64
+	Source string // Source of the estimate, e.g. MOEX fixing USDFIXME.
+}
+
+// Accuracy of estimates in percent of official rates.
+type Accuracy struct {
+	Count int     // Number of estimated rates.
+	Mean  float64 // Mean absolute error.
+	Max   float64 // Maximum absolute error.
+}
+
+// store of history series.
+type store interface {
+	Add(name string, t time.Time, v float64)
+	Series(name string, from time.Time) []history.Point
+}
+
+// forecast estimates tomorrow's official rate of the Russian Central Bank by MOEX intraday data.
+type forecast struct {
+	sync.RWMutex
+	pair     string
+	history  store
+	now      func() time.Time
+	workday  func(t time.Time) bool
+	fixing   func() (moex.Fixing, bool)
+	waprice  func(ctx context.Context, from, to string) (float64, error)
+	official func(ctx context.Context, cur string, t time.Time) (float64, time.Time, error)
+	estimate Estimate
+	actual   float64
+	err      error
+	errDate  time.Time
+}
+
+var (
+	RateInstance *forecast
+	lock         = &sync.Mutex{}
+)
+
+// Get returns instance of forecast of USD/RUB.
+func Get() *forecast {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if RateInstance == nil {
+		RateInstance = &forecast{pair: "USD/RUB", history: history.Get(), now: time.Now,
+			workday: scheduler.DefaultCalendar().IsWorkday,
+			fixing:  fixing.Get().Fixing,
+			waprice: exchange.MOEXWAPrice,
+			official: func(ctx context.Context, cur string, t time.Time) (float64, time.Time, error) {
+				return cbr.NewClient().WithContext(ctx).GetRateDate(cur, t)
+			}}
+	}
+
+	return RateInstance
+}
+
+// Series returns names of history series of estimates and their errors in percent of currency pair, e.g.
+// forecast:cbrf:USD/RUB and forecast:cbrf:USD/RUB:error.
+func Series(pair string) (estimates, errors string) {
+	return "forecast:cbrf:" + pair, "forecast:cbrf:" + pair + ":error"
+}
+
+// Configure currency pair, e.g. CNY/RUB.
+func (r *forecast) Configure(pair string) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.pair != pair {
+		r.pair, r.estimate, r.actual = pair, Estimate{}, 0
+	}
+}
+
+// SetWorkday sets function reporting whether t is a working day.
+func (r *forecast) SetWorkday(f func(t time.Time) bool) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.workday = f
+}
+
+// Update estimate of tomorrow's rate on workdays, until the rate is published.
+// Then record error of the estimate. Rates are fetched without holding the lock.
+func (r *forecast) Update(ctx context.Context) {
+	r.RLock()
+	pair, workday := r.pair, r.workday
+	r.RUnlock()
+
+	now := r.now().In(moex.Moscow)
+	if !workday(now) {
+		return
+	}
+
+	y, m, d := now.Date()
+	target := time.Date(y, m, d+1, 0, 0, 0, 0, moex.Moscow)
+	from, to, _ := strings.Cut(pair, "/")
+
+	actual, date, err := r.official(ctx, from, target)
+	if ctx.Err() != nil {
+		return
+	}
+
+	if err == nil && !date.Before(target) {
+		r.Lock()
+		defer r.Unlock()
+
+		// Pair is reconfigured meanwhile
+		if r.pair != pair {
+			return
+		}
+
+		if r.estimate.Date.Equal(target) && r.actual == 0 {
+			r.resolve(actual)
+		}
+
+		r.actual = actual
+		r.err = nil
+		return
+	}
+
+	e, estErr := r.estimateAt(ctx, now, target, pair)
+	if ctx.Err() != nil {
+		return
+	}
+
+	if estErr != nil {
+		err = estErr
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	if r.pair != pair {
+		return
+	}
+
+	if !r.estimate.Date.Equal(target) {
+		r.actual = 0
+	}
+
+	if estErr == nil {
+		r.estimate = e
+		metrics.Rate.With(source, from+to, "estimate").Set(e.Value)
+	}
+
+	if err != nil {
+		log.Printf("[ERROR] Estimate of %s official rate: %v", pair, err)
+
+		r.err = err
+		r.errDate = r.now()
+		return
+	}
+
+	r.err = nil
+}
+
+// estimateAt returns estimate of rate of pair of target date at now by today's MOEX fixing,
+// or by weighted average price of the day if there's no fixing yet.
+func (r *forecast) estimateAt(ctx context.Context, now, target time.Time, pair string) (Estimate, error) {
+	if f, ok := r.fixing(); ok && f.Code == fixing.Code(pair) && sameDay(f.Time, now) {
+		return Estimate{Date: target, Value: f.Value, Source: "MOEX fixing " + f.Code}, nil
+	}
+
+	from, to, _ := strings.Cut(pair, "/")
+
+	v, err := r.waprice(ctx, from, to)
+	if err != nil {
+		return Estimate{}, err
+	}
+
+	src := "MOEX weighted average price"
+	if !exchange.MOEXDirect(from, to) {
+		src += " (synthetic)"
+	}
+
+	return Estimate{Date: target, Value: v, Source: src}, nil
+}
+
+// resolve estimate by published rate actual, recording it with its error.
+func (r *forecast) resolve(actual float64) {
+	pct := (r.estimate.Value - actual) / actual * 100
+	log.Printf("[INFO] %s official rate of %s is %.4f, estimate was %.4f by %s, error %+.2f%%",
+		r.pair, r.estimate.Date.Format("02.01.2006"), actual, r.estimate.Value, r.estimate.Source, pct)
+
+	estimates, errors := Series(r.pair)
+	r.history.Add(estimates, r.estimate.Date, r.estimate.Value)
+	r.history.Add(errors, r.estimate.Date, pct)
+}
+
+// Accuracy of estimates of history.
+func (r *forecast) Accuracy() Accuracy {
+	r.RLock()
+	_, errors := Series(r.pair)
+	r.RUnlock()
+
+	a := Accuracy{}
+	for _, p := range r.history.Series(errors, time.Time{}) {
+		v := math.Abs(p.Value)
+		a.Count++
+		a.Mean += v
+		a.Max = math.Max(a.Max, v)
+	}
+
+	if a.Count > 0 {
+		a.Mean /= float64(a.Count)
+	}
+
+	return a
+}
+
+// String representation of the estimate of tomorrow's rate, or the published one with error of the estimate,
+// and accuracy of estimates. It's empty if nothing is known.
+func (r *forecast) String() string {
+	r.RLock()
+	_, to, _ := strings.Cut(r.pair, "/")
+	e, actual := r.estimate, r.actual
+	r.RUnlock()
+
+	now := r.now().In(moex.Moscow)
+
+	s := []string{}
+	switch {
+	case e.Value == 0 || e.Date.Before(now):
+	case actual > 0:
+		s = append(s, fmt.Sprintf("Tomorrow:\t%.2f %s, expected ≈ %.2f %s (%+.2f%%)", actual, to, e.Value, to, (e.Value-actual)/actual*100))
+	default:
+		s = append(s, fmt.Sprintf("Expected tomorrow ≈ %.2f %s by %s", e.Value, to, e.Source))
+	}
+
+	if a := r.Accuracy(); a.Count > 0 {
+		s = append(s, fmt.Sprintf("Accuracy:\t±%.2f%% on average, %.2f%% at most, of %d estimates", a.Mean, a.Max, a.Count))
+	}
+
+	return strings.Join(s, "\n")
+}
+
+// sameDay reports whether a and b are the same day in Moscow.
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.In(moex.Moscow).Date()
+	by, bm, bd := b.In(moex.Moscow).Date()
+
+	return ay == by && am == bm && ad == bd
+}
@@ -0,0 +1,107 @@
+package forecast
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ivanglie/usdrub-bot/internal/history"
+	"github.com/ivanglie/usdrub-bot/pkg/moex-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_forecast_Update(t *testing.T) {
+	now := time.Date(2026, 10, 19, 13, 0, 0, 0, moex.Moscow)
+	tomorrow := time.Date(2026, 10, 20, 0, 0, 0, 0, moex.Moscow)
+
+	published := 0.0
+	h := history.New()
+	r := &forecast{pair: "USD/RUB", history: h, now: func() time.Time { return now },
+		workday: func(t time.Time) bool { return t.Weekday() != time.Sunday },
+		fixing:  func() (moex.Fixing, bool) { return moex.Fixing{}, false },
+		waprice: func(ctx context.Context, from, to string) (float64, error) { return 92.1, nil },
+		official: func(ctx context.Context, cur string, date time.Time) (float64, time.Time, error) {
+			assert.Equal(t, "USD", cur)
+			assert.Equal(t, tomorrow, date)
+			if published > 0 {
+				return published, tomorrow, nil
+			}
+			return 91.9, tomorrow.AddDate(0, 0, -1), nil
+		}}
+
+	// Weighted average price before the fixing
+	r.Update(context.Background())
+	assert.NoError(t, r.err)
+	assert.Equal(t, Estimate{Date: tomorrow, Value: 92.1, Source: "MOEX weighted average price (synthetic)"}, r.estimate)
+	assert.Equal(t, "Expected tomorrow ≈ 92.10 RUB by MOEX weighted average price (synthetic)", r.String())
+
+	// Fixing of today
+	r.fixing = func() (moex.Fixing, bool) {
+		return moex.Fixing{Code: moex.USDFIX, Time: now.Add(-30 * time.Minute), Value: 92.4}, true
+	}
+
+	r.Update(context.Background())
+	assert.Equal(t, "MOEX fixing USDFIXME", r.estimate.Source)
+	assert.Equal(t, 92.4, r.estimate.Value)
+
+	// Published
+	published = 92.5
+	r.Update(context.Background())
+	r.Update(context.Background())
+	assert.Equal(t, 92.5, r.actual)
+
+	estimates, errs := Series("USD/RUB")
+	p, ok := h.Last(estimates)
+	assert.True(t, ok)
+	assert.Equal(t, history.Point{Time: tomorrow, Value: 92.4}, p)
+	assert.Len(t, h.Series(errs, time.Time{}), 1)
+
+	// Resolved once
+	r.actual = 0
+	r.Update(context.Background())
+	assert.Len(t, h.Series(errs, time.Time{}), 1)
+
+	a := r.Accuracy()
+	assert.Equal(t, 1, a.Count)
+	assert.InDelta(t, 0.1081, a.Mean, 1e-4)
+	assert.Equal(t, a.Mean, a.Max)
+	assert.Equal(t, "Tomorrow:\t92.50 RUB, expected ≈ 92.40 RUB (-0.11%)\nAccuracy:\t±0.11% on average, 0.11% at most, of 1 estimates", r.String())
+
+	// Next day
+	now = now.AddDate(0, 0, 1)
+	assert.Equal(t, "Accuracy:\t±0.11% on average, 0.11% at most, of 1 estimates", r.String())
+
+	// Not a workday
+	now = time.Date(2026, 10, 25, 13, 0, 0, 0, moex.Moscow)
+	r.Update(context.Background())
+	assert.Equal(t, tomorrow, r.estimate.Date)
+}
+
+func Test_forecast_Update_Error(t *testing.T) {
+	r := &forecast{pair: "CNY/RUB", history: history.New(), now: time.Now,
+		workday: func(t time.Time) bool { return true },
+		fixing:  func() (moex.Fixing, bool) { return moex.Fixing{Code: moex.USDFIX, Time: time.Now(), Value: 92.4}, true },
+		waprice: func(ctx context.Context, from, to string) (float64, error) { return 0, errors.New("error") },
+		official: func(ctx context.Context, cur string, t time.Time) (float64, time.Time, error) {
+			return 0, time.Time{}, errors.New("error")
+		}}
+
+	// Rates are fetched unlocked
+	r.official = func(ctx context.Context, cur string, t time.Time) (float64, time.Time, error) {
+		r.Configure("CNY/RUB")
+		return 0, time.Time{}, errors.New("error")
+	}
+
+	// Fixing of another pair isn't used
+	r.Update(context.Background())
+	assert.Error(t, r.err)
+	assert.Zero(t, r.estimate.Value)
+	assert.Empty(t, r.String())
+}
+
+func TestSeries(t *testing.T) {
+	estimates, errs := Series("CNY/RUB")
+	assert.Equal(t, "forecast:cbrf:CNY/RUB", estimates)
+	assert.Equal(t, "forecast:cbrf:CNY/RUB:error", errs)
+}
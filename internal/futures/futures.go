@@ -0,0 +1,199 @@
+package futures
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ivanglie/usdrub-bot/internal/exchange"
+	"github.com/ivanglie/usdrub-bot/internal/httputil"
+	"github.com/ivanglie/usdrub-bot/internal/metrics"
+	"github.com/ivanglie/usdrub-bot/pkg/moex-go"
+)
+
+const (
+	Prefix = "Front futures on FORTS"
+	Suffix = "by Moscow Exchange"
+
+	source = "moex_futures"
+)
+
+// Quote of the front contract of asset with spot rate of its currency pair.
+type Quote struct {
+	Future    moex.Future
+	Pair      string  // Currency pair, e.g. USD/RUB.
+	Spot      float64 // Spot rate of the pair.
+	Synthetic bool    // Spot rate is a cross rate.
+}
+
+// Premium of the contract price over spot rate.
+func (q Quote) Premium() float64 {
+	return q.Future.Price - q.Spot
+}
+
+// Percent of premium of spot rate.
+func (q Quote) Percent() float64 {
+	return q.Premium() / q.Spot * 100
+}
+
+// Annual premium in percent of spot rate at t, 0 on the expiry day.
+func (q Quote) Annual(t time.Time) float64 {
+	days := q.Future.Days(t)
+	if days <= 0 {
+		return 0
+	}
+
+	return q.Percent() * 365 / float64(days)
+}
+
+// futures represents front contracts of futures assets.
+type futures struct {
+	sync.RWMutex
+	assets  []string
+	f       func(ctx context.Context, asset string) (moex.Future, error)
+	spot    func(ctx context.Context, from, to string) (float64, error)
+	quotes  map[string]Quote
+	err     error
+	errDate time.Time
+}
+
+var (
+	RateInstance *futures
+	lock         = &sync.Mutex{}
+)
+
+// Get returns instance of futures.
+func Get() *futures {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if RateInstance == nil {
+		RateInstance = &futures{assets: []string{moex.Si, moex.CR}, quotes: map[string]Quote{},
+			f: func(ctx context.Context, asset string) (moex.Future, error) {
+				c := moex.NewClient()
+				c.SetFetchFunction(httputil.Get(ctx))
+				return c.GetFrontFuture(asset)
+			},
+			spot: exchange.MOEXQuote}
+	}
+
+	return RateInstance
+}
+
+// Configure assets, e.g. Si.
+func (r *futures) Configure(assets []string) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.assets = append([]string(nil), assets...)
+}
+
+// Update front contracts of every asset and spot rates of their pairs. Quotes are fetched without holding the lock.
+func (r *futures) Update(ctx context.Context) {
+	r.RLock()
+	assets := r.assets
+	r.RUnlock()
+
+	t := time.Now()
+
+	var err error
+	quotes := map[string]Quote{}
+	for _, a := range assets {
+		q, e := r.quote(ctx, a)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if e != nil {
+			log.Printf("[ERROR] %s futures: %v", a, e)
+			err = e
+			continue
+		}
+
+		quotes[a] = q
+		metrics.Rate.With(source, a, "front").Set(q.Future.Price)
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	for a, q := range quotes {
+		r.quotes[a] = q
+	}
+
+	if err != nil {
+		metrics.ObserveFetch(source, t, metrics.ErrType(err))
+
+		r.err = err
+		r.errDate = time.Now()
+		return
+	}
+
+	r.err = nil
+	metrics.ObserveFetch(source, t, "")
+}
+
+// quote of the front contract of asset.
+func (r *futures) quote(ctx context.Context, asset string) (Quote, error) {
+	q := Quote{Pair: moex.FuturesPair(asset)}
+
+	var err error
+	if q.Future, err = r.f(ctx, asset); err != nil {
+		return Quote{}, err
+	}
+
+	from, to, _ := strings.Cut(q.Pair, "/")
+	if q.Spot, err = r.spot(ctx, from, to); err != nil {
+		return Quote{}, fmt.Errorf("spot %s: %w", q.Pair, err)
+	}
+
+	q.Synthetic = !exchange.MOEXDirect(from, to)
+
+	return q, nil
+}
+
+// Quote of the front contract of asset, if it's known.
+func (r *futures) Quote(asset string) (Quote, bool) {
+	r.RLock()
+	defer r.RUnlock()
+
+	q, ok := r.quotes[asset]
+	return q, ok
+}
+
+// String representation of front contracts with their forward premiums over spot.
+func (r *futures) String() string {
+	r.RLock()
+	assets := r.assets
+	r.RUnlock()
+
+	now := time.Now()
+
+	s := []string{}
+	for _, a := range assets {
+		if q, ok := r.Quote(a); ok {
+			s = append(s, q.format(now))
+		}
+	}
+
+	return strings.Join(s, "\n")
+}
+
+// format quote at t, e.g.
+// Si-12.24 (USD/RUB):	92.50 RUB, expires 19.12.2024 in 56 days
+// Premium:	+1.20 RUB (+1.31%, +8.55% p.a.) over spot 91.30 RUB.
+func (q Quote) format(t time.Time) string {
+	_, to, _ := strings.Cut(q.Pair, "/")
+
+	spot := "spot"
+	if q.Synthetic {
+		spot = "synthetic spot"
+	}
+
+	return fmt.Sprintf("%s (%s):\t%.2f %s, expires %s in %d days\nPremium:\t%+.2f %s (%+.2f%%, %+.2f%% p.a.) over %s %.2f %s",
+		q.Future.Name, q.Pair, q.Future.Price, to, q.Future.Expiry.Format("02.01.2006"), q.Future.Days(t),
+		q.Premium(), to, q.Percent(), q.Annual(t), spot, q.Spot, to)
+}
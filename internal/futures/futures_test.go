@@ -0,0 +1,72 @@
+package futures
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ivanglie/usdrub-bot/pkg/moex-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuote(t *testing.T) {
+	now := time.Date(2024, 10, 24, 15, 0, 0, 0, moex.Moscow)
+	q := Quote{
+		Future: moex.Future{Name: "Si-12.24", Asset: moex.Si, Expiry: time.Date(2024, 12, 19, 0, 0, 0, 0, moex.Moscow), Price: 92.5},
+		Pair:   "USD/RUB", Spot: 91.25, Synthetic: true,
+	}
+
+	assert.Equal(t, 1.25, q.Premium())
+	assert.InDelta(t, 1.3699, q.Percent(), 1e-4)
+	assert.InDelta(t, 8.9286, q.Annual(now), 1e-4)
+	assert.Equal(t, "Si-12.24 (USD/RUB):\t92.50 RUB, expires 19.12.2024 in 56 days\n"+
+		"Premium:\t+1.25 RUB (+1.37%, +8.93% p.a.) over synthetic spot 91.25 RUB", q.format(now))
+
+	// Expiry day
+	assert.Equal(t, 0.0, q.Annual(q.Future.Expiry.Add(12*time.Hour)))
+}
+
+func Test_futures_Update(t *testing.T) {
+	r := &futures{assets: []string{moex.Si, moex.CR}, quotes: map[string]Quote{},
+		f: func(ctx context.Context, asset string) (moex.Future, error) {
+			if asset == moex.CR {
+				return moex.Future{}, errors.New("error")
+			}
+
+			return moex.Future{Code: "SiZ4", Name: "Si-12.24", Asset: asset, Expiry: time.Now().AddDate(0, 1, 0), Price: 92.5}, nil
+		},
+		spot: func(ctx context.Context, from, to string) (float64, error) {
+			assert.Equal(t, "USD", from)
+			assert.Equal(t, "RUB", to)
+			return 91.25, nil
+		}}
+
+	r.Update(context.Background())
+	assert.Error(t, r.err)
+
+	q, ok := r.Quote(moex.Si)
+	assert.True(t, ok)
+	assert.Equal(t, 91.25, q.Spot)
+	assert.True(t, q.Synthetic)
+
+	_, ok = r.Quote(moex.CR)
+	assert.False(t, ok)
+
+	assert.Contains(t, r.String(), "Si-12.24 (USD/RUB):\t92.50 RUB")
+	assert.NotContains(t, r.String(), "CNY")
+
+	// Spot error keeps the previous quote
+	r.spot = func(ctx context.Context, from, to string) (float64, error) { return 0, errors.New("error") }
+	r.Configure([]string{moex.Si})
+
+	r.Update(context.Background())
+	assert.ErrorContains(t, r.err, "spot USD/RUB")
+
+	q, _ = r.Quote(moex.Si)
+	assert.Equal(t, 91.25, q.Spot)
+}
+
+func TestGet(t *testing.T) {
+	assert.Equal(t, Get(), Get())
+}
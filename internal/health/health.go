@@ -0,0 +1,154 @@
+package health
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultThreshold is the default maximum age of exchange rates for readiness.
+	DefaultThreshold = 12 * time.Hour
+
+	// DefaultHeartbeat is the default maximum interval between receiver heartbeats for liveness.
+	DefaultHeartbeat = 3 * time.Minute
+)
+
+// health represents liveness and readiness status of the bot.
+type health struct {
+	sync.RWMutex
+	threshold  time.Duration
+	heartbeat  time.Duration
+	receiving  bool
+	beatDate   time.Time
+	updatesErr error
+	sources    map[string]func() time.Time
+}
+
+var (
+	healthInstance *health
+	lock           = &sync.Mutex{}
+)
+
+// Get returns instance of health.
+func Get() *health {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if healthInstance == nil {
+		healthInstance = &health{threshold: DefaultThreshold, heartbeat: DefaultHeartbeat, sources: map[string]func() time.Time{}}
+	}
+
+	return healthInstance
+}
+
+// SetThreshold sets maximum age of exchange rates for readiness.
+func (h *health) SetThreshold(d time.Duration) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.threshold = d
+}
+
+// SetHeartbeat sets maximum interval between receiver heartbeats for liveness.
+func (h *health) SetHeartbeat(d time.Duration) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.heartbeat = d
+}
+
+// AddSource registers exchange source by name with function that returns time of its last successful update.
+func (h *health) AddSource(name string, updated func() time.Time) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.sources[name] = updated
+}
+
+// SetReceiving marks update receiver as running or stopped.
+func (h *health) SetReceiving(b bool) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.receiving = b
+	h.beatDate = time.Now()
+}
+
+// Beat records receiver heartbeat and result of the last getUpdates call.
+func (h *health) Beat(err error) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.beatDate = time.Now()
+	h.updatesErr = err
+}
+
+// Live returns error if update receiver is not running or wedged.
+func (h *health) Live() error {
+	h.RLock()
+	defer h.RUnlock()
+
+	if !h.receiving {
+		return errors.New("update receiver is not running")
+	}
+
+	if d := time.Since(h.beatDate); d > h.heartbeat {
+		return fmt.Errorf("no heartbeat from update receiver for %v", d.Round(time.Second))
+	}
+
+	return nil
+}
+
+// Ready returns error if the last getUpdates call failed or every exchange source is out of date.
+func (h *health) Ready() error {
+	if err := h.Live(); err != nil {
+		return err
+	}
+
+	h.RLock()
+	defer h.RUnlock()
+
+	if h.updatesErr != nil {
+		return fmt.Errorf("last getUpdates call failed: %v", h.updatesErr)
+	}
+
+	if len(h.sources) == 0 {
+		return nil
+	}
+
+	for _, updated := range h.sources {
+		if time.Since(updated()) <= h.threshold {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("every exchange source is older than %v", h.threshold)
+}
+
+// LiveHandler returns HTTP handler for liveness probe.
+func LiveHandler() http.Handler {
+	return handler(func() error { return Get().Live() })
+}
+
+// ReadyHandler returns HTTP handler for readiness probe.
+func ReadyHandler() http.Handler {
+	return handler(func() error { return Get().Ready() })
+}
+
+// handler responds with 200 OK if check passes, and 503 Service Unavailable otherwise.
+func handler(check func() error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+		if err := check(); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintln(w, err)
+			return
+		}
+
+		fmt.Fprintln(w, "ok")
+	})
+}
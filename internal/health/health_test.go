@@ -0,0 +1,70 @@
+package health
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_health_Live(t *testing.T) {
+	h := &health{heartbeat: time.Minute, sources: map[string]func() time.Time{}}
+	assert.Error(t, h.Live())
+
+	h.SetReceiving(true)
+	assert.NoError(t, h.Live())
+
+	// Wedged receiver
+	h.beatDate = time.Now().Add(-2 * time.Minute)
+	assert.Error(t, h.Live())
+
+	h.Beat(nil)
+	assert.NoError(t, h.Live())
+
+	// Longer heartbeat interval
+	h.beatDate = time.Now().Add(-2 * time.Minute)
+	h.SetHeartbeat(3 * time.Minute)
+	assert.NoError(t, h.Live())
+
+	h.SetReceiving(false)
+	assert.Error(t, h.Live())
+}
+
+func Test_health_Ready(t *testing.T) {
+	h := &health{threshold: time.Hour, heartbeat: time.Minute, sources: map[string]func() time.Time{}}
+	h.SetReceiving(true)
+	assert.NoError(t, h.Ready())
+
+	h.AddSource("s1", func() time.Time { return time.Now().Add(-2 * time.Hour) })
+	h.AddSource("s2", func() time.Time { return time.Now() })
+	assert.NoError(t, h.Ready())
+
+	// Every source is out of date
+	h.AddSource("s2", func() time.Time { return time.Time{} })
+	assert.Error(t, h.Ready())
+
+	h.SetThreshold(3 * time.Hour)
+	assert.NoError(t, h.Ready())
+
+	// getUpdates error
+	h.Beat(errors.New("error"))
+	assert.Error(t, h.Ready())
+}
+
+func TestHandlers(t *testing.T) {
+	Get().SetReceiving(true)
+
+	rec := httptest.NewRecorder()
+	LiveHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	Get().Beat(errors.New("error"))
+
+	rec = httptest.NewRecorder()
+	ReadyHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), "getUpdates")
+}
@@ -0,0 +1,172 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// Retention of points.
+	Retention = 30 * 24 * time.Hour
+
+	file = "history.json"
+)
+
+// Point of a series.
+type Point struct {
+	Time  time.Time `json:"t"`
+	Value float64   `json:"v"`
+}
+
+// store of time series by name, kept in memory and flushed to a JSON file.
+type store struct {
+	sync.RWMutex
+	path   string
+	series map[string][]Point
+	dirty  bool
+}
+
+var (
+	storeInstance *store
+	lock          = &sync.Mutex{}
+)
+
+// Get returns instance of the store.
+func Get() *store {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if storeInstance == nil {
+		storeInstance = New()
+	}
+
+	return storeInstance
+}
+
+// New returns empty store kept in memory until it's opened.
+func New() *store {
+	return &store{series: map[string][]Point{}}
+}
+
+// Open loads series of the file in dir, which is created on flush if it doesn't exist.
+// Dir is created if it doesn't exist. Without it the store is kept in memory only.
+func (s *store) Open(dir string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, file)
+
+	b, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	series := map[string][]Point{}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &series); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	}
+
+	s.path, s.series, s.dirty = path, series, false
+
+	return nil
+}
+
+// Add point of value v at t to series name, dropping points older than Retention.
+// A point at the same time is replaced.
+func (s *store) Add(name string, t time.Time, v float64) {
+	s.Lock()
+	defer s.Unlock()
+
+	pp := s.series[name]
+	i := sort.Search(len(pp), func(i int) bool { return !pp[i].Time.Before(t) })
+	if i < len(pp) && pp[i].Time.Equal(t) {
+		pp[i].Value = v
+	} else {
+		pp = append(pp, Point{})
+		copy(pp[i+1:], pp[i:])
+		pp[i] = Point{t, v}
+	}
+
+	old := sort.Search(len(pp), func(i int) bool { return t.Sub(pp[i].Time) <= Retention })
+	s.series[name] = pp[old:]
+	s.dirty = true
+}
+
+// Series returns points of series name since from.
+func (s *store) Series(name string, from time.Time) []Point {
+	s.RLock()
+	defer s.RUnlock()
+
+	pp := s.series[name]
+	i := sort.Search(len(pp), func(i int) bool { return !pp[i].Time.Before(from) })
+
+	return append([]Point(nil), pp[i:]...)
+}
+
+// At returns the last point of series name at or before t.
+func (s *store) At(name string, t time.Time) (Point, bool) {
+	s.RLock()
+	defer s.RUnlock()
+
+	pp := s.series[name]
+	i := sort.Search(len(pp), func(i int) bool { return pp[i].Time.After(t) })
+	if i == 0 {
+		return Point{}, false
+	}
+
+	return pp[i-1], true
+}
+
+// Last returns the last point of series name.
+func (s *store) Last(name string) (Point, bool) {
+	s.RLock()
+	defer s.RUnlock()
+
+	pp := s.series[name]
+	if len(pp) == 0 {
+		return Point{}, false
+	}
+
+	return pp[len(pp)-1], true
+}
+
+// Flush series to the file, if the store is opened and changed.
+func (s *store) Flush() error {
+	s.Lock()
+	defer s.Unlock()
+
+	if len(s.path) == 0 || !s.dirty {
+		return nil
+	}
+
+	b, err := json.Marshal(s.series)
+	if err != nil {
+		return err
+	}
+
+	// Rename is atomic, so the file is never written partially
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+
+	s.dirty = false
+
+	return nil
+}
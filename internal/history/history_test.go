@@ -0,0 +1,71 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+	assert.Equal(t, Get(), Get())
+}
+
+func Test_store(t *testing.T) {
+	s := New()
+	now := time.Date(2023, 7, 3, 12, 0, 0, 0, time.UTC)
+
+	s.Add("a", now.Add(-2*time.Hour), 1)
+	s.Add("a", now, 3)
+	s.Add("a", now.Add(-time.Hour), 2)
+	s.Add("b", now, 10)
+
+	assert.Equal(t, []Point{{now.Add(-time.Hour), 2}, {now, 3}}, s.Series("a", now.Add(-time.Hour)))
+	assert.Empty(t, s.Series("c", now))
+
+	p, ok := s.At("a", now.Add(-90*time.Minute))
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, p.Value)
+
+	_, ok = s.At("a", now.Add(-3*time.Hour))
+	assert.False(t, ok)
+
+	p, ok = s.Last("a")
+	assert.True(t, ok)
+	assert.Equal(t, 3.0, p.Value)
+
+	// Same time is replaced
+	s.Add("b", now, 11)
+	assert.Equal(t, []Point{{now, 11}}, s.Series("b", time.Time{}))
+
+	// Retention
+	s.Add("a", now.Add(Retention-90*time.Minute), 4)
+	assert.Len(t, s.Series("a", time.Time{}), 3)
+}
+
+func Test_store_Flush(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "data")
+	now := time.Date(2023, 7, 3, 12, 0, 0, 0, time.UTC)
+
+	// Not opened
+	s := &store{series: map[string][]Point{}}
+	s.Add("a", now, 1)
+	assert.NoError(t, s.Flush())
+
+	assert.NoError(t, s.Open(dir))
+	assert.Empty(t, s.Series("a", time.Time{}))
+
+	s.Add("a", now, 1)
+	assert.NoError(t, s.Flush())
+	assert.FileExists(t, filepath.Join(dir, file))
+
+	s = &store{series: map[string][]Point{}}
+	assert.NoError(t, s.Open(dir))
+	assert.Equal(t, []Point{{now, 1}}, s.Series("a", time.Time{}))
+
+	// Invalid
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte("{"), 0o644))
+	assert.Error(t, s.Open(dir))
+}
@@ -0,0 +1,18 @@
+package httputil
+
+import (
+	"context"
+	"net/http"
+)
+
+// Get returns function that mimics http.Get() method with context.
+func Get(ctx context.Context) func(url string) (*http.Response, error) {
+	return func(url string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		return http.DefaultClient.Do(req)
+	}
+}
@@ -0,0 +1,32 @@
+package httputil
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer srv.Close()
+
+	resp, err := Get(context.Background())(srv.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+
+	// Canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = Get(ctx)(srv.URL)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = Get(context.Background())("://")
+	assert.Error(t, err)
+}
@@ -0,0 +1,376 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Bot metrics.
+var (
+	Rate = NewGaugeVec("usdrub_rate",
+		"Current exchange rate in RUB by source and pair.", "source", "pair", "kind")
+	LastUpdate = NewGaugeVec("usdrub_last_update_timestamp_seconds",
+		"Unix time of the last successful update by source.", "source")
+	FetchDuration = NewHistogramVec("usdrub_fetch_duration_seconds",
+		"Duration of fetching rates by source.", DefBuckets, "source")
+	FetchErrors = NewCounterVec("usdrub_fetch_errors_total",
+		"Number of failed fetches by source and error type.", "source", "type")
+	Commands = NewCounterVec("usdrub_commands_total",
+		"Number of handled commands and callbacks by name.", "command")
+	SendFailures = NewCounterVec("usdrub_telegram_send_failures_total",
+		"Number of failed Telegram send calls.")
+	ParseStrategy = NewGaugeVec("usdrub_parse_strategy",
+		"Parsing strategy of the last successful scrape by source, 1 if used.", "source", "strategy")
+	ScrapeRows = NewGaugeVec("usdrub_scrape_rows",
+		"Number of rows found on the page by the last scrape by source.", "source")
+	ScrapeHitRatio = NewGaugeVec("usdrub_scrape_hit_ratio",
+		"Share of rows parsed by the last scrape by source.", "source")
+	Drift = NewGaugeVec("usdrub_layout_drift",
+		"1 if layout drift of the source page is detected.", "source")
+	Premium = NewGaugeVec("usdrub_usdt_premium_percent",
+		"Premium of USDT rate over official USD/RUB rate in percent by direction and base.", "direction", "base")
+)
+
+// Error types.
+const (
+	ErrFetch = "fetch" // Source returned an error.
+	ErrEmpty = "empty" // Source returned no data without an error.
+	ErrDrift = "drift" // Source page loaded, but nothing matched.
+)
+
+// DefBuckets are the default histogram buckets, in seconds.
+var DefBuckets = []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30}
+
+// collector is a metric family that can be written in Prometheus text format.
+type collector interface {
+	write(w io.Writer)
+}
+
+var (
+	collectors []collector
+	lock       = &sync.Mutex{}
+)
+
+// register adds collector to the default registry.
+func register(c collector) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	collectors = append(collectors, c)
+}
+
+// Handler returns HTTP handler that exposes metrics in Prometheus text format.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		Write(w)
+	})
+}
+
+// Write all registered metrics to w.
+func Write(w io.Writer) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+// ObserveFetch records duration of the fetch started at t, and error of the given type if it's not empty.
+func ObserveFetch(source string, t time.Time, errType string) {
+	FetchDuration.With(source).Observe(time.Since(t).Seconds())
+
+	if len(errType) > 0 {
+		FetchErrors.With(source, errType).Inc()
+		return
+	}
+
+	LastUpdate.With(source).Set(float64(time.Now().Unix()))
+}
+
+// ErrType returns error type for the failed fetch.
+func ErrType(err error) string {
+	if err != nil {
+		return ErrFetch
+	}
+
+	return ErrEmpty
+}
+
+// family holds common fields of metric vectors.
+type family struct {
+	sync.RWMutex
+	name   string
+	help   string
+	typ    string
+	labels []string
+}
+
+// key of label values.
+func (f *family) key(values []string) string {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+
+	return strings.Join(values, "\xff")
+}
+
+// labelPairs represented as string, e.g. {source="moex",pair="USDRUB"}.
+func (f *family) labelPairs(key string, extra ...string) string {
+	var s []string
+	if len(f.labels) > 0 {
+		for i, v := range strings.Split(key, "\xff") {
+			s = append(s, fmt.Sprintf("%s=\"%s\"", f.labels[i], escape(v)))
+		}
+	}
+
+	for i := 0; i+1 < len(extra); i += 2 {
+		s = append(s, fmt.Sprintf("%s=\"%s\"", extra[i], escape(extra[i+1])))
+	}
+
+	if len(s) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(s, ",") + "}"
+}
+
+// labelEscaper escapes label values by the text exposition format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escape label value v, e.g. a "b" as a \"b\".
+func escape(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+// header writes HELP and TYPE lines.
+func (f *family) header(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.typ)
+}
+
+// value is a float64 metric value safe for concurrent use.
+type value struct {
+	sync.Mutex
+	v float64
+}
+
+// Set value.
+func (v *value) Set(f float64) {
+	v.Lock()
+	defer v.Unlock()
+
+	v.v = f
+}
+
+// Add delta to value.
+func (v *value) Add(f float64) {
+	v.Lock()
+	defer v.Unlock()
+
+	v.v += f
+}
+
+// Inc increments value by 1.
+func (v *value) Inc() {
+	v.Add(1)
+}
+
+// get returns current value.
+func (v *value) get() float64 {
+	v.Lock()
+	defer v.Unlock()
+
+	return v.v
+}
+
+// valueVec is a vector of values partitioned by label values.
+type valueVec struct {
+	family
+	values map[string]*value
+}
+
+// with returns value for label values, creating it if needed.
+func (vv *valueVec) with(values []string) *value {
+	k := vv.key(values)
+
+	vv.Lock()
+	defer vv.Unlock()
+
+	v, ok := vv.values[k]
+	if !ok {
+		v = &value{}
+		vv.values[k] = v
+	}
+
+	return v
+}
+
+// write all values.
+func (vv *valueVec) write(w io.Writer) {
+	vv.RLock()
+	defer vv.RUnlock()
+
+	vv.header(w)
+	for _, k := range sortedKeys(vv.values) {
+		fmt.Fprintf(w, "%s%s %s\n", vv.name, vv.labelPairs(k), formatFloat(vv.values[k].get()))
+	}
+}
+
+// Gauge is a metric that can go up and down.
+type Gauge interface {
+	Set(float64)
+	Add(float64)
+}
+
+// GaugeVec is a gauge partitioned by label values.
+type GaugeVec struct {
+	valueVec
+}
+
+// NewGaugeVec creates and registers a new GaugeVec.
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{valueVec{family{name: name, help: help, typ: "gauge", labels: labels}, map[string]*value{}}}
+	register(g)
+
+	return g
+}
+
+// With returns gauge for label values.
+func (g *GaugeVec) With(values ...string) Gauge {
+	return g.with(values)
+}
+
+// Counter is a metric that only goes up.
+type Counter interface {
+	Inc()
+}
+
+// CounterVec is a counter partitioned by label values.
+type CounterVec struct {
+	valueVec
+}
+
+// NewCounterVec creates and registers a new CounterVec.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{valueVec{family{name: name, help: help, typ: "counter", labels: labels}, map[string]*value{}}}
+	register(c)
+
+	return c
+}
+
+// With returns counter for label values.
+func (c *CounterVec) With(values ...string) Counter {
+	return c.with(values)
+}
+
+// Histogram counts observations in configurable buckets.
+type Histogram struct {
+	sync.Mutex
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+// Observe adds a single observation.
+func (h *Histogram) Observe(v float64) {
+	h.Lock()
+	defer h.Unlock()
+
+	for i, b := range h.buckets {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+
+	h.count++
+	h.sum += v
+}
+
+// HistogramVec is a histogram partitioned by label values.
+type HistogramVec struct {
+	family
+	buckets []float64
+	values  map[string]*Histogram
+}
+
+// NewHistogramVec creates and registers a new HistogramVec.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	b := append([]float64{}, buckets...)
+	sort.Float64s(b)
+
+	h := &HistogramVec{family{name: name, help: help, typ: "histogram", labels: labels}, b, map[string]*Histogram{}}
+	register(h)
+
+	return h
+}
+
+// With returns histogram for label values.
+func (hv *HistogramVec) With(values ...string) *Histogram {
+	k := hv.key(values)
+
+	hv.Lock()
+	defer hv.Unlock()
+
+	h, ok := hv.values[k]
+	if !ok {
+		h = &Histogram{buckets: hv.buckets, counts: make([]uint64, len(hv.buckets))}
+		hv.values[k] = h
+	}
+
+	return h
+}
+
+// write all histograms.
+func (hv *HistogramVec) write(w io.Writer) {
+	hv.RLock()
+	defer hv.RUnlock()
+
+	hv.header(w)
+	for _, k := range sortedKeys(hv.values) {
+		h := hv.values[k]
+		h.Lock()
+		for i, b := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", hv.name, hv.labelPairs(k, "le", formatFloat(b)), h.counts[i])
+		}
+
+		fmt.Fprintf(w, "%s_bucket%s %d\n", hv.name, hv.labelPairs(k, "le", "+Inf"), h.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", hv.name, hv.labelPairs(k), formatFloat(h.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", hv.name, hv.labelPairs(k), h.count)
+		h.Unlock()
+	}
+}
+
+// sortedKeys returns keys of m in ascending order.
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// formatFloat in Prometheus text format.
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
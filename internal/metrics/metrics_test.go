@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGaugeVec(t *testing.T) {
+	g := NewGaugeVec("test_gauge", "Test gauge.", "source")
+	g.With("a").Set(1.5)
+	g.With("b").Set(2)
+	g.With("b").Add(0.5)
+
+	b := &bytes.Buffer{}
+	g.write(b)
+
+	assert.Equal(t, "# HELP test_gauge Test gauge.\n# TYPE test_gauge gauge\n"+
+		"test_gauge{source=\"a\"} 1.5\ntest_gauge{source=\"b\"} 2.5\n", b.String())
+
+	// Wrong number of label values
+	assert.Panics(t, func() { g.With("a", "b") })
+
+	// Escaped label values
+	g = NewGaugeVec("test_gauge", "Test gauge.", "source")
+	g.With("Сбер \"a\"\\\n").Set(1)
+
+	b.Reset()
+	g.write(b)
+	assert.Contains(t, b.String(), "test_gauge{source=\"Сбер \\\"a\\\"\\\\\\n\"} 1\n")
+}
+
+func TestCounterVec(t *testing.T) {
+	c := NewCounterVec("test_counter", "Test counter.")
+	c.With().Inc()
+	c.With().Inc()
+
+	b := &bytes.Buffer{}
+	c.write(b)
+
+	assert.Equal(t, "# HELP test_counter Test counter.\n# TYPE test_counter counter\ntest_counter 2\n", b.String())
+}
+
+func TestHistogramVec(t *testing.T) {
+	h := NewHistogramVec("test_histogram", "Test histogram.", []float64{1, 0.5}, "source")
+	h.With("a").Observe(0.3)
+	h.With("a").Observe(0.7)
+	h.With("a").Observe(2)
+
+	b := &bytes.Buffer{}
+	h.write(b)
+
+	assert.Equal(t, "# HELP test_histogram Test histogram.\n# TYPE test_histogram histogram\n"+
+		"test_histogram_bucket{source=\"a\",le=\"0.5\"} 1\n"+
+		"test_histogram_bucket{source=\"a\",le=\"1\"} 2\n"+
+		"test_histogram_bucket{source=\"a\",le=\"+Inf\"} 3\n"+
+		"test_histogram_sum{source=\"a\"} 3\n"+
+		"test_histogram_count{source=\"a\"} 3\n", b.String())
+}
+
+func TestObserveFetch(t *testing.T) {
+	ObserveFetch("test", time.Now(), "")
+	ObserveFetch("test", time.Now(), ErrType(errors.New("error")))
+	ObserveFetch("test", time.Now(), ErrType(nil))
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	s := rec.Body.String()
+	assert.Contains(t, s, "usdrub_fetch_duration_seconds_count{source=\"test\"} 3\n")
+	assert.Contains(t, s, "usdrub_fetch_errors_total{source=\"test\",type=\"fetch\"} 1\n")
+	assert.Contains(t, s, "usdrub_fetch_errors_total{source=\"test\",type=\"empty\"} 1\n")
+	assert.Contains(t, s, "usdrub_last_update_timestamp_seconds{source=\"test\"}")
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+}
+
+func Test_formatFloat(t *testing.T) {
+	assert.Equal(t, "+Inf", formatFloat(math.Inf(1)))
+	assert.Equal(t, "-Inf", formatFloat(math.Inf(-1)))
+	assert.Equal(t, "NaN", formatFloat(math.NaN()))
+	assert.Equal(t, "96.41", formatFloat(96.41))
+}
@@ -0,0 +1,175 @@
+package premium
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ivanglie/usdrub-bot/internal/crypto"
+	"github.com/ivanglie/usdrub-bot/internal/exchange"
+	"github.com/ivanglie/usdrub-bot/internal/history"
+	"github.com/ivanglie/usdrub-bot/internal/metrics"
+	"github.com/ivanglie/usdrub-bot/pkg/bestchange-go"
+)
+
+// Premium of USDT rate over an official USD/RUB rate.
+type Premium struct {
+	Base     string  // Name of the official rate, e.g. Forex.
+	Official float64 // Official rate.
+	Rate     float64 // USDT rate.
+	RUB      float64 // Premium in RUB.
+	Percent  float64 // Premium in percent of the official rate.
+}
+
+// bases are official rates by exchange name with their keys of series and metrics.
+var bases = []struct {
+	name string
+	key  string
+}{
+	{exchange.Forex, "forex"},
+	{exchange.MOEX, "moex"},
+	{exchange.CBRF, "cbrf"},
+}
+
+// Of returns premiums of USDT rate of direction d over enabled official USD/RUB rates.
+// Other directions and unknown rates have no premiums.
+func Of(d bestchange.Direction) []Premium {
+	if d.Asset().Code() != "USDT" || d.Quote().Code() != "RUB" {
+		return nil
+	}
+
+	rate := crypto.For(d).Value()
+	if rate == 0 {
+		return nil
+	}
+
+	pp := []Premium{}
+	for _, b := range bases {
+		v := exchange.Get().Value(b.name)
+		if v == nil || !v.Enabled() || v.Pair() != "USD/RUB" || v.Rate() == 0 {
+			continue
+		}
+
+		pp = append(pp, compute(b.name, v.Rate(), rate))
+	}
+
+	return pp
+}
+
+// compute premium of rate over official one of base.
+func compute(base string, official, rate float64) Premium {
+	return Premium{Base: base, Official: official, Rate: rate, RUB: rate - official, Percent: (rate - official) / official * 100}
+}
+
+// String of premiums, one per line.
+func String(pp []Premium) string {
+	s := []string{}
+	for _, p := range pp {
+		s = append(s, fmt.Sprintf("Premium:\t%+.2f RUB (%+.2f%%) over %s", p.RUB, p.Percent, p.Base))
+	}
+
+	return strings.Join(s, "\n")
+}
+
+// Series returns name of history series of premium in percent of direction d over base, e.g.
+// premium:cash-ruble-to-tether-trc20-in-msk:forex.
+func Series(d bestchange.Direction, base string) string {
+	for _, b := range bases {
+		if b.name == base {
+			base = b.key
+		}
+	}
+
+	return fmt.Sprintf("premium:%s:%s", d, base)
+}
+
+// monitor of premiums, which records them and alerts when they exceed the threshold.
+type monitor struct {
+	sync.Mutex
+	notify    func(text string)
+	threshold float64
+	alerted   map[string]bool
+}
+
+var (
+	monitorInstance *monitor
+	lock            = &sync.Mutex{}
+)
+
+// Get returns instance of the monitor.
+func Get() *monitor {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if monitorInstance == nil {
+		monitorInstance = &monitor{alerted: map[string]bool{}}
+	}
+
+	return monitorInstance
+}
+
+// SetNotifier sets function to notify of premiums exceeding the threshold, e.g. to send message to admins.
+func (m *monitor) SetNotifier(f func(text string)) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.notify = f
+}
+
+// SetThreshold of alerts in percent, 0 disables them.
+func (m *monitor) SetThreshold(percent float64) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.threshold = percent
+}
+
+// Observe premiums pp of direction d: record them to history and metrics, and alert once
+// when absolute premium exceeds the threshold and once when it's back.
+// Alerts are sent outside of the lock.
+func (m *monitor) Observe(d bestchange.Direction, pp []Premium) {
+	now := time.Now()
+	for _, p := range pp {
+		history.Get().Add(Series(d, p.Base), now, p.Percent)
+		metrics.Premium.With(d.String(), p.Base).Set(p.Percent)
+	}
+
+	m.Lock()
+	alerts := m.transitions(d, pp)
+	notify := m.notify
+	m.Unlock()
+
+	if notify == nil {
+		return
+	}
+
+	for _, text := range alerts {
+		notify(text)
+	}
+}
+
+// transitions of alert states of premiums pp of direction d, returning alerts to send.
+func (m *monitor) transitions(d bestchange.Direction, pp []Premium) []string {
+	alerts := []string{}
+	for _, p := range pp {
+		name := Series(d, p.Base)
+
+		above := m.threshold > 0 && math.Abs(p.Percent) >= m.threshold
+		switch {
+		case above && !m.alerted[name]:
+			log.Printf("[WARNING] %s premium over %s is %+.2f%%, threshold is %.2f%%", d, p.Base, p.Percent, m.threshold)
+			alerts = append(alerts, fmt.Sprintf("⚠️ %s: premium over %s is %+.2f RUB (%+.2f%%), threshold is %.2f%%",
+				d, p.Base, p.RUB, p.Percent, m.threshold))
+			m.alerted[name] = true
+		case !above && m.alerted[name]:
+			log.Printf("[INFO] %s premium over %s is back to %+.2f%%", d, p.Base, p.Percent)
+			alerts = append(alerts, fmt.Sprintf("✅ %s: premium over %s is back to %+.2f%%", d, p.Base, p.Percent))
+			m.alerted[name] = false
+		}
+	}
+
+	return alerts
+}
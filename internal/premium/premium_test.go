@@ -0,0 +1,73 @@
+package premium
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ivanglie/usdrub-bot/internal/exchange"
+	"github.com/ivanglie/usdrub-bot/internal/history"
+	"github.com/ivanglie/usdrub-bot/pkg/bestchange-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_compute(t *testing.T) {
+	p := compute(exchange.Forex, 80, 84)
+	assert.Equal(t, 4.0, p.RUB)
+	assert.Equal(t, 5.0, p.Percent)
+
+	p = compute(exchange.CBRF, 80, 78)
+	assert.Equal(t, -2.0, p.RUB)
+	assert.Equal(t, -2.5, p.Percent)
+}
+
+func TestString(t *testing.T) {
+	pp := []Premium{compute(exchange.Forex, 80, 84), compute(exchange.CBRF, 80, 78)}
+	assert.Equal(t, "Premium:\t+4.00 RUB (+5.00%) over Forex\nPremium:\t-2.00 RUB (-2.50%) over Russian Central Bank", String(pp))
+	assert.Empty(t, String(nil))
+}
+
+func TestOf(t *testing.T) {
+	// Selling BTC
+	assert.Empty(t, Of(bestchange.Direction{Give: bestchange.BTC, Get: bestchange.CashRUB, City: bestchange.Moscow}))
+}
+
+func TestSeries(t *testing.T) {
+	assert.Equal(t, "premium:cash-ruble-to-tether-trc20-in-msk:moex", Series(bestchange.DefaultDirection, exchange.MOEX))
+}
+
+func Test_monitor_Observe(t *testing.T) {
+	d := bestchange.Direction{Give: bestchange.CashRUB, Get: bestchange.USDTBEP20, City: bestchange.Kazan}
+
+	texts := []string{}
+	m := &monitor{alerted: map[string]bool{}}
+	m.SetNotifier(func(text string) {
+		// Monitor isn't locked while notifying
+		m.Lock()
+		defer m.Unlock()
+
+		texts = append(texts, text)
+	})
+
+	// Disabled
+	m.Observe(d, []Premium{compute(exchange.Forex, 80, 88)})
+	assert.Empty(t, texts)
+
+	p, ok := history.Get().Last(Series(d, exchange.Forex))
+	assert.True(t, ok)
+	assert.Equal(t, 10.0, p.Value)
+	assert.WithinDuration(t, time.Now(), p.Time, time.Second)
+
+	m.SetThreshold(5)
+	m.Observe(d, []Premium{compute(exchange.Forex, 80, 88)})
+	m.Observe(d, []Premium{compute(exchange.Forex, 80, 89)})
+	assert.Len(t, texts, 1)
+	assert.Contains(t, texts[0], "+8.00 RUB (+10.00%)")
+
+	m.Observe(d, []Premium{compute(exchange.Forex, 80, 81)})
+	assert.Len(t, texts, 2)
+	assert.Contains(t, texts[1], "back to +1.25%")
+
+	// Discount
+	m.Observe(d, []Premium{compute(exchange.Forex, 80, 72)})
+	assert.Len(t, texts, 3)
+}
@@ -0,0 +1,105 @@
+package receiver
+
+import (
+	"context"
+	"log"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanglie/usdrub-bot/internal/health"
+)
+
+// Receiver delivers Telegram updates.
+type Receiver interface {
+	// Updates returns a channel for getting updates, which is closed when ctx is done.
+	Updates(ctx context.Context) tgbotapi.UpdatesChannel
+}
+
+// Poller receives updates using long polling.
+type Poller struct {
+	bot     *tgbotapi.BotAPI
+	timeout int
+	retry   time.Duration
+	ch      chan tgbotapi.Update
+}
+
+// NewPoller creates a new Poller with long polling timeout in seconds.
+func NewPoller(bot *tgbotapi.BotAPI, timeout int) *Poller {
+	return &Poller{bot: bot, timeout: timeout, retry: 3 * time.Second, ch: make(chan tgbotapi.Update, bot.Buffer)}
+}
+
+// Updates starts long polling and returns a channel for getting updates.
+// Unlike bot.GetUpdatesChan, it reports heartbeats and getUpdates errors to health,
+// and stops when ctx is done without waiting for the pending getUpdates call.
+// Updates of the abandoned call are not confirmed, so Telegram delivers them again.
+func (p *Poller) Updates(ctx context.Context) tgbotapi.UpdatesChannel {
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = p.timeout
+
+	// getUpdates doesn't work while an outgoing webhook is set up.
+	if _, err := p.bot.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+		log.Printf("[ERROR] Failed to delete webhook: %v", err)
+	}
+
+	go func() {
+		health.Get().SetReceiving(true)
+		defer health.Get().SetReceiving(false)
+		defer close(p.ch)
+
+		for {
+			updates, err := p.getUpdates(ctx, u)
+			if ctx.Err() != nil {
+				return
+			}
+
+			health.Get().Beat(err)
+			if err != nil {
+				log.Printf("[ERROR] Failed to get updates, retrying in %v: %v", p.retry, err)
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(p.retry):
+				}
+
+				continue
+			}
+
+			for _, update := range updates {
+				if update.UpdateID < u.Offset {
+					continue
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case p.ch <- update:
+					u.Offset = update.UpdateID + 1
+				}
+			}
+		}
+	}()
+
+	return p.ch
+}
+
+// getUpdates calls bot.GetUpdates and returns early when ctx is done.
+func (p *Poller) getUpdates(ctx context.Context, u tgbotapi.UpdateConfig) ([]tgbotapi.Update, error) {
+	type result struct {
+		updates []tgbotapi.Update
+		err     error
+	}
+
+	ch := make(chan result, 1)
+	go func() {
+		updates, err := p.bot.GetUpdates(u)
+		ch <- result{updates, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-ch:
+		return r.updates, r.err
+	}
+}
@@ -0,0 +1,211 @@
+package receiver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAPI is a local fake of Telegram Bot API.
+type fakeAPI struct {
+	sync.Mutex
+	webhookURL string
+	secret     string
+	calls      map[string]int
+	updates    []tgbotapi.Update
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	f.calls[method]++
+
+	var result interface{} = true
+	switch method {
+	case "getMe":
+		result = tgbotapi.User{ID: 1, IsBot: true, UserName: "test_bot"}
+	case "setWebhook":
+		f.webhookURL, f.secret = r.Form.Get("url"), r.Form.Get("secret_token")
+	case "deleteWebhook":
+		f.webhookURL, f.secret = "", ""
+	case "getWebhookInfo":
+		result = tgbotapi.WebhookInfo{URL: f.webhookURL}
+	case "getUpdates":
+		result, f.updates = f.updates, nil
+	}
+
+	b, _ := json.Marshal(result)
+	fmt.Fprintf(w, `{"ok":true,"result":%s}`, b)
+}
+
+func newFakeBot(t *testing.T) (*tgbotapi.BotAPI, *fakeAPI) {
+	f := &fakeAPI{calls: map[string]int{}}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint("token", srv.URL+"/bot%s/%s")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return bot, f
+}
+
+func TestPoller_Updates(t *testing.T) {
+	bot, f := newFakeBot(t)
+	f.webhookURL = "https://example.com/hook"
+	f.updates = []tgbotapi.Update{{UpdateID: 1, Message: &tgbotapi.Message{Text: "/moex"}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := NewPoller(bot, 0).Updates(ctx)
+
+	select {
+	case u := <-updates:
+		assert.Equal(t, 1, u.UpdateID)
+		assert.Equal(t, "/moex", u.Message.Text)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no update received")
+	}
+
+	f.Lock()
+	assert.Empty(t, f.webhookURL)
+	f.Unlock()
+
+	// Stop
+	cancel()
+	assertClosed(t, updates)
+}
+
+func assertClosed(t *testing.T, updates tgbotapi.UpdatesChannel) {
+	for {
+		select {
+		case _, ok := <-updates:
+			if !ok {
+				return
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("updates channel is not closed")
+		}
+	}
+}
+
+func TestNewWebhook(t *testing.T) {
+	bot, _ := newFakeBot(t)
+
+	w, err := NewWebhook(bot, "https://example.com/hook", "secret", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, "/hook", w.Path())
+
+	w, err = NewWebhook(bot, "https://example.com", "secret", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, "/", w.Path())
+
+	// Errors
+	_, err = NewWebhook(bot, "http://example.com/hook", "secret", time.Minute)
+	assert.Error(t, err)
+
+	_, err = NewWebhook(bot, "https://example.com/hook", "", time.Minute)
+	assert.Error(t, err)
+
+	_, err = NewWebhook(bot, "https://example.com/hook", "not secret", time.Minute)
+	assert.Error(t, err)
+
+	_, err = NewWebhook(bot, "https://example.com/hook", "secret", 0)
+	assert.Error(t, err)
+}
+
+func TestWebhook_Updates(t *testing.T) {
+	bot, f := newFakeBot(t)
+
+	w, err := NewWebhook(bot, "https://example.com/hook", "secret", time.Minute)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := w.Updates(ctx)
+
+	f.Lock()
+	assert.Equal(t, "https://example.com/hook", f.webhookURL)
+	assert.Equal(t, "secret", f.secret)
+	f.Unlock()
+
+	// Update with valid secret token
+	r := httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader(`{"update_id":7,"message":{"text":"/cbrf"}}`))
+	r.Header.Set(SecretHeader, "secret")
+	rec := httptest.NewRecorder()
+	w.ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	u := <-updates
+	assert.Equal(t, 7, u.UpdateID)
+	assert.Equal(t, "/cbrf", u.Message.Text)
+
+	// Invalid secret token
+	r = httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader(`{"update_id":8}`))
+	r.Header.Set(SecretHeader, "wrong")
+	rec = httptest.NewRecorder()
+	w.ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Invalid method
+	rec = httptest.NewRecorder()
+	w.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/hook", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	// Invalid body
+	r = httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader(`{`))
+	r.Header.Set(SecretHeader, "secret")
+	rec = httptest.NewRecorder()
+	w.ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Stop
+	cancel()
+	assertClosed(t, updates)
+
+	r = httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader(`{"update_id":9}`))
+	r.Header.Set(SecretHeader, "secret")
+	rec = httptest.NewRecorder()
+	w.ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	f.Lock()
+	assert.Equal(t, "https://example.com/hook", f.webhookURL)
+	f.Unlock()
+}
+
+func TestWebhook_check(t *testing.T) {
+	bot, f := newFakeBot(t)
+
+	w, err := NewWebhook(bot, "https://example.com/hook", "secret", time.Minute)
+	assert.NoError(t, err)
+
+	// Not registered
+	assert.NoError(t, w.check())
+	assert.Equal(t, 1, f.calls["setWebhook"])
+
+	// Registered
+	assert.NoError(t, w.check())
+	assert.Equal(t, 1, f.calls["setWebhook"])
+
+	// Replaced
+	f.webhookURL = "https://example.org/other"
+	assert.NoError(t, w.check())
+	assert.Equal(t, 2, f.calls["setWebhook"])
+	assert.Equal(t, "https://example.com/hook", f.webhookURL)
+}
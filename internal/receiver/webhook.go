@@ -0,0 +1,174 @@
+package receiver
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ivanglie/usdrub-bot/internal/health"
+)
+
+// SecretHeader is the header with secret token sent by Telegram in every webhook request.
+const SecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// See https://core.telegram.org/bots/api#setwebhook
+var secretRe = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+// Webhook receives updates sent by Telegram to the HTTP listener.
+type Webhook struct {
+	sync.RWMutex
+	bot      *tgbotapi.BotAPI
+	url      *url.URL
+	secret   string
+	interval time.Duration
+	ch       chan tgbotapi.Update
+	ctx      context.Context
+	closed   bool
+}
+
+// NewWebhook creates a new Webhook for public URL and secret token.
+// Registration is checked and renewed every interval.
+func NewWebhook(bot *tgbotapi.BotAPI, link, secret string, interval time.Duration) (*Webhook, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "https" || len(u.Host) == 0 {
+		return nil, fmt.Errorf("webhook URL must be absolute https URL: %s", link)
+	}
+
+	if !secretRe.MatchString(secret) {
+		return nil, errors.New("webhook secret must be 1-256 characters A-Z, a-z, 0-9, _ and -")
+	}
+
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid webhook check interval: %v", interval)
+	}
+
+	return &Webhook{bot: bot, url: u, secret: secret, interval: interval, ch: make(chan tgbotapi.Update, bot.Buffer),
+		ctx: context.Background()}, nil
+}
+
+// Path of the webhook URL to serve updates on.
+func (w *Webhook) Path() string {
+	if len(w.url.Path) == 0 {
+		return "/"
+	}
+
+	return w.url.Path
+}
+
+// Register sets webhook with secret token.
+// It's safe to call from several replicas, since Telegram keeps a single webhook per bot.
+func (w *Webhook) Register() error {
+	params := tgbotapi.Params{}
+	params["url"] = w.url.String()
+	params["secret_token"] = w.secret
+
+	_, err := w.bot.MakeRequest("setWebhook", params)
+
+	return err
+}
+
+// Updates registers webhook and returns a channel for getting updates.
+// Webhook is re-registered, if it was removed or replaced (e.g. by a bot in polling mode).
+// Webhook stays registered when ctx is done, so that other replicas keep receiving updates.
+func (w *Webhook) Updates(ctx context.Context) tgbotapi.UpdatesChannel {
+	w.Lock()
+	w.ctx = ctx
+	w.Unlock()
+
+	if err := w.Register(); err != nil {
+		log.Printf("[ERROR] Failed to register webhook: %v", err)
+	}
+
+	go func() {
+		health.Get().SetReceiving(true)
+		defer health.Get().SetReceiving(false)
+		defer w.close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(w.interval):
+				health.Get().Beat(w.check())
+			}
+		}
+	}()
+
+	return w.ch
+}
+
+// close updates channel.
+func (w *Webhook) close() {
+	w.Lock()
+	defer w.Unlock()
+
+	w.closed = true
+	close(w.ch)
+}
+
+// check webhook info and re-register webhook if needed.
+func (w *Webhook) check() error {
+	info, err := w.bot.GetWebhookInfo()
+	if err != nil {
+		return err
+	}
+
+	if len(info.LastErrorMessage) > 0 {
+		log.Printf("[WARNING] Webhook delivery error at %v: %s",
+			time.Unix(int64(info.LastErrorDate), 0), info.LastErrorMessage)
+	}
+
+	if info.URL == w.url.String() {
+		return nil
+	}
+
+	log.Printf("[WARNING] Webhook URL is %q, re-registering %q", info.URL, w.url)
+
+	return w.Register()
+}
+
+// ServeHTTP validates secret token and sends the update to the channel.
+func (w *Webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get(SecretHeader)), []byte(w.secret)) != 1 {
+		http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	var update tgbotapi.Update
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.RLock()
+	defer w.RUnlock()
+
+	if w.closed {
+		http.Error(rw, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	select {
+	case <-w.ctx.Done():
+		http.Error(rw, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+	case w.ch <- update:
+	}
+}
@@ -0,0 +1,137 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ivanglie/usdrub-bot/internal/cash"
+	"github.com/ivanglie/usdrub-bot/internal/crypto"
+	"github.com/ivanglie/usdrub-bot/pkg/bankiru-go"
+	"github.com/ivanglie/usdrub-bot/pkg/bestchange-go"
+)
+
+// Route of buying cash currency with RUB, priced end to end.
+type Route struct {
+	Title string   // Title of the route, e.g. bank branch.
+	Steps []string // Steps of the route as HTML.
+	Cost  float64  // Total cost in RUB.
+	Rate  float64  // Effective rate in RUB per 1 of the currency.
+}
+
+// router prices routes by rates of cash and crypto providers.
+type router struct {
+	// bestSell returns the branch selling currency cur at the lowest rate.
+	bestSell func(cur string) (bankiru.Branch, bool)
+	// best returns the best offer of direction d to get amount, with amount given for it.
+	best func(d bestchange.Direction, amount float64) (bestchange.Offer, float64, bool)
+}
+
+// New returns router by current rates of cash and crypto providers.
+func New() *router {
+	return &router{
+		bestSell: func(cur string) (bankiru.Branch, bool) {
+			return cash.For(bankiru.Currency(cur)).BestSell()
+		},
+		best: func(d bestchange.Direction, amount float64) (bestchange.Offer, float64, bool) {
+			return crypto.For(d).Best(amount)
+		}}
+}
+
+// Parse arguments of amount and currency, e.g. 5000 usd. Currency is USD if omitted.
+func Parse(args []string) (float64, string, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return 0, "", errors.New("amount and currency are expected, e.g. 5000 usd")
+	}
+
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(args[0], ",", "."), 64)
+	if err != nil || amount <= 0 {
+		return 0, "", fmt.Errorf("invalid amount %q", args[0])
+	}
+
+	cur := "USD"
+	if len(args) == 2 {
+		cur = strings.ToUpper(args[1])
+	}
+
+	return amount, cur, nil
+}
+
+// ViaBranch prices buying amount of currency cur at the bank branch selling it at the lowest rate.
+func (r *router) ViaBranch(amount float64, cur string) (Route, bool) {
+	b, ok := r.bestSell(cur)
+	if !ok {
+		return Route{}, false
+	}
+
+	step := fmt.Sprintf("Buy at %.2f RUB: %s, %s", b.Sell, html.EscapeString(b.Bank), html.EscapeString(b.Subway))
+
+	return Route{Title: "bank branch", Steps: []string{step}, Cost: amount * b.Sell, Rate: b.Sell}, true
+}
+
+// ViaCrypto prices buying amount of cash currency cur with cash RUB through cryptocurrency,
+// by pairs of directions dd in the same city: RUB to cryptocurrency and cryptocurrency to cur.
+// Offers are the best ones within their limits.
+func (r *router) ViaCrypto(amount float64, cur string, dd []bestchange.Direction) []Route {
+	rr := []Route{}
+	for _, sell := range dd {
+		if !sell.Selling() || sell.Get.Code() != cur {
+			continue
+		}
+
+		for _, buy := range dd {
+			if buy.Selling() || buy.Give != bestchange.CashRUB || buy.Get != sell.Give || buy.City != sell.City {
+				continue
+			}
+
+			o2, asset, ok := r.best(sell, amount)
+			if !ok {
+				continue
+			}
+
+			o1, rub, ok := r.best(buy, asset)
+			if !ok {
+				continue
+			}
+
+			title := sell.Give.Title()
+			rr = append(rr, Route{
+				Title: fmt.Sprintf("via %s in %s", title, sell.City.Title()),
+				Steps: []string{
+					fmt.Sprintf("Exchange %.0f RUB for %.2f %s at %.2f RUB: %s", rub, asset, title, o1.Rate, html.EscapeString(o1.Exchanger)),
+					fmt.Sprintf("Exchange %.2f %s for %s %s at %.4f %s: %s", asset, title, strconv.FormatFloat(amount, 'f', -1, 64), cur,
+						o2.Rate, cur, html.EscapeString(o2.Exchanger)),
+				},
+				Cost: rub,
+				Rate: rub / amount,
+			})
+		}
+	}
+
+	return rr
+}
+
+// Rank routes by total cost, the cheapest first.
+func Rank(rr []Route) []Route {
+	sort.SliceStable(rr, func(i, j int) bool { return rr[i].Cost < rr[j].Cost })
+	return rr
+}
+
+// String of ranked routes rr with their costs, and overpayment over the cheapest one.
+func String(rr []Route, cur string) string {
+	s := []string{}
+	for i, r := range rr {
+		line := fmt.Sprintf("%d. %.0f RUB (%.2f RUB per %s", i+1, r.Cost, r.Rate, cur)
+		if i > 0 {
+			line += fmt.Sprintf(", +%.0f RUB", r.Cost-rr[0].Cost)
+		}
+
+		line += "), " + r.Title
+		s = append(s, line+"\n"+strings.Join(r.Steps, "\n"))
+	}
+
+	return strings.Join(s, "\n")
+}
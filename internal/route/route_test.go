@@ -0,0 +1,78 @@
+package route
+
+import (
+	"testing"
+
+	"github.com/ivanglie/usdrub-bot/pkg/bankiru-go"
+	"github.com/ivanglie/usdrub-bot/pkg/bestchange-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	amount, cur, err := Parse([]string{"5000", "usd"})
+	assert.NoError(t, err)
+	assert.Equal(t, 5000.0, amount)
+	assert.Equal(t, "USD", cur)
+
+	amount, cur, err = Parse([]string{"100,5"})
+	assert.NoError(t, err)
+	assert.Equal(t, 100.5, amount)
+	assert.Equal(t, "USD", cur)
+
+	for _, args := range [][]string{nil, {"usd"}, {"-1", "usd"}, {"1", "usd", "now"}} {
+		_, _, err = Parse(args)
+		assert.Error(t, err, args)
+	}
+}
+
+func TestViaBranch(t *testing.T) {
+	r := &router{bestSell: func(cur string) (bankiru.Branch, bool) {
+		return bankiru.Branch{Bank: "A&B", Subway: "s", Sell: 92.5}, cur == "USD"
+	}}
+
+	v, ok := r.ViaBranch(5000, "USD")
+	assert.True(t, ok)
+	assert.Equal(t, Route{Title: "bank branch", Steps: []string{"Buy at 92.50 RUB: A&amp;B, s"}, Cost: 462500, Rate: 92.5}, v)
+
+	_, ok = r.ViaBranch(5000, "GBP")
+	assert.False(t, ok)
+}
+
+func TestViaCrypto(t *testing.T) {
+	buy := bestchange.Direction{Give: bestchange.CashRUB, Get: bestchange.USDTBEP20, City: bestchange.Novosibirsk}
+	sell := bestchange.Direction{Give: bestchange.USDTBEP20, Get: bestchange.CashUSD, City: bestchange.Novosibirsk}
+	other := bestchange.Direction{Give: bestchange.CashRUB, Get: bestchange.USDTBEP20, City: bestchange.Kazan}
+
+	r := &router{best: func(d bestchange.Direction, amount float64) (bestchange.Offer, float64, bool) {
+		switch d {
+		case buy:
+			return bestchange.Offer{Exchanger: "A", Rate: 95}, amount * 95, true
+		case sell:
+			return bestchange.Offer{Exchanger: "C", Rate: 0.95}, amount / 0.95, true
+		case other:
+			return bestchange.Offer{Exchanger: "D", Rate: 90}, amount * 90, true
+		}
+
+		return bestchange.Offer{}, 0, false
+	}}
+
+	rr := r.ViaCrypto(1900, "USD", []bestchange.Direction{other, buy, sell})
+	assert.Len(t, rr, 1)
+	assert.Equal(t, "via USDT (BEP20) in Novosibirsk", rr[0].Title)
+	assert.InDelta(t, 190000.0, rr[0].Cost, 1e-6)
+	assert.InDelta(t, 100.0, rr[0].Rate, 1e-9)
+	assert.Equal(t, []string{"Exchange 190000 RUB for 2000.00 USDT (BEP20) at 95.00 RUB: A",
+		"Exchange 2000.00 USDT (BEP20) for 1900 USD at 0.9500 USD: C"}, rr[0].Steps)
+
+	assert.Empty(t, r.ViaCrypto(1900, "EUR", []bestchange.Direction{buy, sell}))
+}
+
+func TestString(t *testing.T) {
+	rr := Rank([]Route{
+		{Title: "via USDT", Steps: []string{"b"}, Cost: 471000, Rate: 94.2},
+		{Title: "bank branch", Steps: []string{"a"}, Cost: 462500, Rate: 92.5},
+	})
+
+	assert.Equal(t, "1. 462500 RUB (92.50 RUB per USD), bank branch\na\n2. 471000 RUB (94.20 RUB per USD, +8500 RUB), via USDT\nb",
+		String(rr, "USD"))
+}
@@ -0,0 +1,220 @@
+package scheduler
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed calendar.txt
+var calendarData []byte
+
+// Kind of the calendar day.
+type Kind string
+
+// Kinds.
+const (
+	Holiday    Kind = "holiday"    // Non-working day.
+	Workday    Kind = "workday"    // Working day transferred to a weekend.
+	NonTrading Kind = "nontrading" // Working day without trading on MOEX.
+)
+
+// Days a job runs on.
+type Days int
+
+const (
+	EveryDay    Days = iota // Every day.
+	TradingDays             // MOEX trading days only.
+)
+
+// String representation of days.
+func (d Days) String() string {
+	if d == TradingDays {
+		return "trading days"
+	}
+
+	return "every day"
+}
+
+const dateFormat = "2006-01-02"
+
+// Calendar of Russian public holidays and MOEX non-trading days.
+type Calendar struct {
+	sync.RWMutex
+	days   map[string]Kind
+	years  map[int]bool // Years listed in the calendar.
+	warned map[int]bool // Years not listed, which are warned about.
+}
+
+var (
+	calendarInstance *Calendar
+	calendarLock     = &sync.Mutex{}
+)
+
+// DefaultCalendar returns instance of the embedded calendar.
+func DefaultCalendar() *Calendar {
+	calendarLock.Lock()
+	defer calendarLock.Unlock()
+
+	if calendarInstance == nil {
+		c, err := LoadCalendar("")
+		if err != nil {
+			panic(err)
+		}
+
+		calendarInstance = c
+	}
+
+	return calendarInstance
+}
+
+// LoadCalendar creates a new Calendar of the embedded days and ones of the calendar file at path, if it's set.
+func LoadCalendar(path string) (*Calendar, error) {
+	c, err := NewCalendar(bytes.NewReader(calendarData))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(path) > 0 {
+		if err := c.Load(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// NewCalendar creates a new Calendar from r.
+// Each line is "<YYYY-MM-DD> <kind> [comment]", empty lines and lines starting with # are ignored.
+func NewCalendar(r io.Reader) (*Calendar, error) {
+	c := &Calendar{days: map[string]Kind{}, years: map[int]bool{}, warned: map[int]bool{}}
+	if err := c.read(r); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Load days from the calendar file at path, which override days of c.
+func (c *Calendar) Load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return c.read(f)
+}
+
+// read days from r.
+func (c *Calendar) read(r io.Reader) error {
+	days, years := map[string]Kind{}, map[int]bool{}
+
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return fmt.Errorf("calendar line %d: want date and kind, got %q", n, line)
+		}
+
+		d, err := time.Parse(dateFormat, fields[0])
+		if err != nil {
+			return fmt.Errorf("calendar line %d: %v", n, err)
+		}
+
+		switch k := Kind(fields[1]); k {
+		case Holiday, Workday, NonTrading:
+			days[fields[0]], years[d.Year()] = k, true
+		default:
+			return fmt.Errorf("calendar line %d: unknown kind %q", n, k)
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	for d, k := range days {
+		c.days[d] = k
+	}
+
+	for y := range years {
+		c.years[y] = true
+	}
+
+	return nil
+}
+
+// Covers reports whether year of t is listed in the calendar.
+// Otherwise only weekends are non-working days, which is warned about once a year.
+func (c *Calendar) Covers(t time.Time) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	y := t.Year()
+	if c.years[y] {
+		return true
+	}
+
+	if !c.warned[y] {
+		log.Printf("[WARNING] Calendar doesn't list %d, holidays and MOEX non-trading days are unknown, "+
+			"add them with a calendar file", y)
+		c.warned[y] = true
+	}
+
+	return false
+}
+
+// kind returns kind of the day t, if it's listed.
+func (c *Calendar) kind(t time.Time) (Kind, bool) {
+	c.Covers(t)
+
+	c.RLock()
+	defer c.RUnlock()
+
+	k, ok := c.days[t.Format(dateFormat)]
+
+	return k, ok
+}
+
+// IsWorkday reports whether t is a working day in Russia.
+func (c *Calendar) IsWorkday(t time.Time) bool {
+	if k, ok := c.kind(t); ok {
+		return k != Holiday
+	}
+
+	return t.Weekday() != time.Saturday && t.Weekday() != time.Sunday
+}
+
+// IsTradingDay reports whether t is a trading day on MOEX.
+func (c *Calendar) IsTradingDay(t time.Time) bool {
+	if k, ok := c.kind(t); ok && k == NonTrading {
+		return false
+	}
+
+	return c.IsWorkday(t)
+}
+
+// Match reports whether a job with days policy d runs on t.
+func (c *Calendar) Match(d Days, t time.Time) bool {
+	if d == TradingDays {
+		return c.IsTradingDay(t)
+	}
+
+	return true
+}
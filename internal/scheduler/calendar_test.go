@@ -0,0 +1,101 @@
+package scheduler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse(dateFormat, s)
+	return t
+}
+
+func TestDefaultCalendar(t *testing.T) {
+	c := DefaultCalendar()
+
+	// Weekday
+	assert.True(t, c.IsWorkday(date("2026-10-19")))
+	assert.True(t, c.IsTradingDay(date("2026-10-19")))
+
+	// Weekend
+	assert.False(t, c.IsWorkday(date("2026-10-18")))
+	assert.False(t, c.IsTradingDay(date("2026-10-18")))
+
+	// Holiday
+	assert.False(t, c.IsWorkday(date("2026-11-04")))
+	assert.False(t, c.IsTradingDay(date("2026-11-04")))
+
+	// Working Saturday without trading
+	assert.True(t, c.IsWorkday(date("2025-11-01")))
+	assert.False(t, c.IsTradingDay(date("2025-11-01")))
+
+	assert.True(t, c.Covers(date("2026-10-19")))
+	assert.False(t, c.Covers(date("2030-10-21")))
+
+	assert.True(t, c.Match(EveryDay, date("2026-11-04")))
+	assert.False(t, c.Match(TradingDays, date("2026-11-04")))
+}
+
+func TestNewCalendar(t *testing.T) {
+	c, err := NewCalendar(strings.NewReader("# Comment\n\n2026-10-20 nontrading Technical day\n"))
+	assert.NoError(t, err)
+	assert.True(t, c.IsWorkday(date("2026-10-20")))
+	assert.False(t, c.IsTradingDay(date("2026-10-20")))
+	assert.True(t, c.Covers(date("2026-01-01")))
+	assert.False(t, c.Covers(date("2025-10-20")))
+
+	// Errors
+	_, err = NewCalendar(strings.NewReader("2026-10-20"))
+	assert.Error(t, err)
+
+	_, err = NewCalendar(strings.NewReader("20.10.2026 holiday"))
+	assert.Error(t, err)
+
+	_, err = NewCalendar(strings.NewReader("2026-10-20 vacation"))
+	assert.Error(t, err)
+}
+
+func TestCalendar_Load(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calendar.txt")
+	if err := os.WriteFile(path, []byte("2026-10-19 holiday\n2026-10-18 workday\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewCalendar(strings.NewReader("2026-11-04 holiday"))
+	assert.NoError(t, err)
+	assert.NoError(t, c.Load(path))
+
+	assert.False(t, c.IsWorkday(date("2026-10-19")))
+	assert.True(t, c.IsWorkday(date("2026-10-18")))
+	assert.False(t, c.IsWorkday(date("2026-11-04")))
+
+	// Errors
+	assert.Error(t, c.Load(filepath.Join(t.TempDir(), "unknown.txt")))
+}
+
+func TestLoadCalendar(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calendar.txt")
+	if err := os.WriteFile(path, []byte("2026-10-19 holiday\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadCalendar(path)
+	assert.NoError(t, err)
+	assert.False(t, c.IsWorkday(date("2026-10-19")))
+	assert.False(t, c.IsWorkday(date("2026-11-04")))
+
+	// Days of the file aren't kept by the next calendar
+	c, err = LoadCalendar("")
+	assert.NoError(t, err)
+	assert.True(t, c.IsWorkday(date("2026-10-19")))
+	assert.True(t, DefaultCalendar().IsWorkday(date("2026-10-19")))
+
+	// Errors
+	_, err = LoadCalendar(filepath.Join(t.TempDir(), "unknown.txt"))
+	assert.Error(t, err)
+}
@@ -1,38 +1,93 @@
 package scheduler
 
 import (
+	"context"
+	"fmt"
 	"log"
-	"os"
+	"math/rand"
+	"sync/atomic"
 	"time"
 
 	"github.com/ivanglie/usdrub-bot/internal/logger"
 	"github.com/robfig/cron/v3"
 )
 
-// StartCmdOnSchedule specified by cmd.
-func StartCmdOnSchedule(cmd func()) (err error) {
-	spec := os.Getenv("CRON_SPEC")
-	if spec == "" {
-		spec = "* * * * 1-5" // See https://crontab.guru/
-	}
+// Job is a command run on schedule.
+type Job struct {
+	Name   string
+	Spec   string        // See https://crontab.guru/
+	Jitter time.Duration // Maximum random delay before each run.
+	Days   Days
+	Cmd    func(ctx context.Context)
+}
 
-	if logger.Debug {
-		log.Printf("[DEBUG] Cron spec = %s\n", spec)
+// Start jobs on schedule in location loc.
+// A run is skipped if the previous run of the same job is still in progress, or the day doesn't match
+// the job days by calendar cal.
+// Scheduler stops when ctx is done, and the returned channel is closed when running jobs complete.
+func Start(ctx context.Context, loc *time.Location, cal *Calendar, jobs ...Job) (done <-chan struct{}, err error) {
+	c := cron.New(cron.WithLocation(loc))
+	for _, j := range jobs {
+		if logger.Debug {
+			log.Printf("[DEBUG] Cron spec of %s = %s, jitter = %v, days = %v\n", j.Name, j.Spec, j.Jitter, j.Days)
+		}
+
+		if _, err = c.AddFunc(j.Spec, j.run(ctx, cal, loc)); err != nil {
+			err = fmt.Errorf("%s: %v", j.Name, err)
+			return
+		}
 	}
 
-	moscowTime, err := time.LoadLocation("Europe/Moscow")
+	c.Start()
+
+	ch := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		<-c.Stop().Done()
+		close(ch)
+	}()
+
+	done = ch
+
+	return
+}
+
+// Interval between the next two runs of cron spec after t.
+func Interval(spec string, t time.Time) (time.Duration, error) {
+	s, err := cron.ParseStandard(spec)
 	if err != nil {
-		return
+		return 0, err
 	}
 
-	c := cron.New(cron.WithLocation(moscowTime))
-	defer c.Stop()
+	next := s.Next(t)
 
-	if _, err = c.AddFunc(spec, cmd); err != nil {
-		return
-	}
+	return s.Next(next).Sub(next), nil
+}
 
-	go c.Start()
+// run returns function that runs the job command after random delay,
+// unless the previous run is in progress or the current day in loc doesn't match the job days.
+func (j Job) run(ctx context.Context, cal *Calendar, loc *time.Location) func() {
+	var running int32
 
-	return
+	return func() {
+		if !cal.Match(j.Days, time.Now().In(loc)) {
+			return
+		}
+
+		if !atomic.CompareAndSwapInt32(&running, 0, 1) {
+			log.Printf("[WARNING] %s is still running, skipped", j.Name)
+			return
+		}
+		defer atomic.StoreInt32(&running, 0)
+
+		if j.Jitter > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Duration(rand.Int63n(int64(j.Jitter)))):
+			}
+		}
+
+		j.Cmd(ctx)
+	}
 }
@@ -1,14 +1,98 @@
 package scheduler
 
 import (
+	"context"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/ivanglie/usdrub-bot/internal/logger"
+	"github.com/stretchr/testify/assert"
 )
 
-func TestStartCmdOnSchedule(t *testing.T) {
+func TestStart(t *testing.T) {
 	logger.Debug = true
-	if err := StartCmdOnSchedule(func() {}); err != nil {
-		t.Errorf("StartCmdOnSchedule() error = %v", err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	loc, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	done, err := Start(ctx, loc, DefaultCalendar(),
+		Job{Name: "j1", Spec: "* * * * 1-5", Cmd: func(ctx context.Context) {}},
+		Job{Name: "j2", Spec: "*/10 * * * *", Jitter: time.Second, Days: TradingDays, Cmd: func(ctx context.Context) {}})
+	assert.NoError(t, err)
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("Start() is not stopped")
 	}
+
+	// Invalid spec
+	_, err = Start(context.Background(), loc, DefaultCalendar(), Job{Name: "j3", Spec: "invalid", Cmd: func(ctx context.Context) {}})
+	assert.ErrorContains(t, err, "j3")
+}
+
+func TestInterval(t *testing.T) {
+	d, err := Interval("*/5 * * * *", time.Date(2026, 10, 19, 12, 1, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, d)
+
+	_, err = Interval("invalid", time.Now())
+	assert.Error(t, err)
+}
+
+func TestJob_run(t *testing.T) {
+	var count int32
+
+	started, release := make(chan struct{}), make(chan struct{})
+	j := Job{Name: "j", Cmd: func(ctx context.Context) {
+		atomic.AddInt32(&count, 1)
+		started <- struct{}{}
+		<-release
+	}}
+
+	run := j.run(context.Background(), DefaultCalendar(), time.UTC)
+	finished := make(chan struct{})
+	go func() {
+		run()
+		close(finished)
+	}()
+	<-started
+
+	// Overlapping run is skipped
+	run()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+
+	release <- struct{}{}
+	<-finished
+
+	go run()
+	<-started
+	release <- struct{}{}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&count))
+
+	// Canceled during jitter
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	j = Job{Name: "j", Jitter: time.Hour, Cmd: func(ctx context.Context) { atomic.AddInt32(&count, 1) }}
+	j.run(ctx, DefaultCalendar(), time.UTC)()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&count))
+
+	// Not a trading day
+	cal, err := NewCalendar(strings.NewReader(time.Now().UTC().Format(dateFormat) + " holiday"))
+	assert.NoError(t, err)
+
+	j = Job{Name: "j", Days: TradingDays, Cmd: func(ctx context.Context) { atomic.AddInt32(&count, 1) }}
+	j.run(context.Background(), cal, time.UTC)()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&count))
+
+	j.Days = EveryDay
+	j.run(context.Background(), cal, time.UTC)()
+	assert.Equal(t, int32(3), atomic.LoadInt32(&count))
 }
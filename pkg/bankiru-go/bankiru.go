@@ -3,35 +3,85 @@ package bankiru
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strconv"
 	"time"
 )
 
 // Bank or branch.
+// The list page has no address, phone, opening hours and coordinates of branches,
+// so they're empty for parsed branches.
 type Branch struct {
-	Bank     string    `json:"bank"`
-	Subway   string    `json:"subway"`
-	Currency string    `json:"currency"`
-	Buy      float64   `json:"buy"`
-	Sell     float64   `json:"sell"`
-	Updated  time.Time `json:"updated"`
+	Bank      string    `json:"bank"`
+	Subway    string    `json:"subway"`
+	Currency  string    `json:"currency"`
+	Buy       float64   `json:"buy"`
+	Sell      float64   `json:"sell"`
+	Updated   time.Time `json:"updated"`
+	Address   string    `json:"address,omitempty"`
+	Phone     string    `json:"phone,omitempty"`
+	Hours     string    `json:"hours,omitempty"`
+	Latitude  float64   `json:"latitude,omitempty"`
+	Longitude float64   `json:"longitude,omitempty"`
+}
+
+// HasLocation reports whether coordinates of the branch are known.
+func (b Branch) HasLocation() bool {
+	return b.Latitude != 0 || b.Longitude != 0
+}
+
+// MapURL returns link to the branch on Yandex Maps by coordinates, or by address if they're unknown.
+// It returns empty string if both are unknown.
+func (b Branch) MapURL() string {
+	if b.HasLocation() {
+		ll := strconv.FormatFloat(b.Longitude, 'f', -1, 64) + "," + strconv.FormatFloat(b.Latitude, 'f', -1, 64)
+		return "https://yandex.ru/maps/?pt=" + ll + "&z=16&l=map"
+	}
+
+	if len(b.Address) > 0 {
+		return "https://yandex.ru/maps/?text=" + url.QueryEscape(b.Bank+", "+b.Address)
+	}
+
+	return ""
 }
 
 // Currency type.
 type Currency string
 
+// Supported reports whether the currency is supported by the client.
+func (c Currency) Supported() bool {
+	for _, v := range Currencies {
+		if v == c {
+			return true
+		}
+	}
+
+	return false
+}
+
 // City type.
 type City string
 
 // Banks or branches.
 type Branches struct {
-	Currency Currency `json:"currency"`
-	City     City     `json:"city"`
-	Items    []Branch `json:"items"`
+	Currency   Currency `json:"currency"`
+	City       City     `json:"city"`
+	Items      []Branch `json:"items"`
+	Strategy   Strategy `json:"strategy,omitempty"`   // Strategy the items are parsed by.
+	Rows       int      `json:"rows,omitempty"`       // Number of rows found by the strategy, including invalid ones.
+	Duplicates int      `json:"duplicates,omitempty"` // Number of branches listed more than once.
+	Stale      []Stale  `json:"stale,omitempty"`      // Branches excluded for being out of date.
+}
+
+// Stale branch, which is excluded for being out of date.
+type Stale struct {
+	Branch Branch `json:"branch"`
+	Reason string `json:"reason"`
 }
 
 // NewBranch creates a new Branch instance.
 func newBranch(bank, subway, currency string, buy, sell float64, updated time.Time) Branch {
-	return Branch{bank, subway, currency, buy, sell, updated}
+	return Branch{Bank: bank, Subway: subway, Currency: currency, Buy: buy, Sell: sell, Updated: updated}
 }
 
 // ByBuySorter implements sort.Interface based on the Buy field.
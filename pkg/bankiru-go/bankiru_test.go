@@ -73,7 +73,7 @@ func TestRates_String(t *testing.T) {
 		time.Date(2023, time.January, 24, 16, 54, 0, 0, loc))
 
 	r := &Branches{}
-	r.Currency = currency
+	r.Currency = USD
 	r.City = Novosibirsk
 	r.Items = []Branch{b}
 
@@ -97,3 +97,21 @@ func TestRates_String(t *testing.T) {
 		t.Errorf("got = %v, want \"\" (emtpy)", got)
 	}
 }
+
+func TestBranch_MapURL(t *testing.T) {
+	b := newBranch("bank", "subway", "USD", 79.61, 81.64, time.Now())
+	if got := b.MapURL(); len(got) > 0 {
+		t.Errorf("MapURL() = %v, want \"\" (empty)", got)
+	}
+
+	b.Address = "Красный проспект, 25"
+	if got, want := b.MapURL(), "https://yandex.ru/maps/?text=bank%2C+%D0%9A%D1%80%D0%B0%D1%81%D0%BD%D1%8B%D0%B9+"+
+		"%D0%BF%D1%80%D0%BE%D1%81%D0%BF%D0%B5%D0%BA%D1%82%2C+25"; got != want {
+		t.Errorf("MapURL() = %v, want %v", got, want)
+	}
+
+	b.Latitude, b.Longitude = 55.030199, 82.92043
+	if got, want := b.MapURL(), "https://yandex.ru/maps/?pt=82.92043,55.030199&z=16&l=map"; got != want {
+		t.Errorf("MapURL() = %v, want %v", got, want)
+	}
+}
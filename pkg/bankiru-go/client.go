@@ -1,6 +1,8 @@
 package bankiru
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
@@ -16,8 +18,16 @@ const (
 	// Example: https://www.banki.ru/products/currency/map/moskva/.
 	baseURL = "https://www.banki.ru/products/currency/map/%s/"
 
+	// Example: https://www.banki.ru/products/currency/map/eur/moskva/.
+	currencyURL = "https://www.banki.ru/products/currency/map/%s/%s/"
+
 	// Currency.
-	currency = "USD"
+	USD Currency = "USD"
+	EUR Currency = "EUR"
+	CNY Currency = "CNY"
+
+	// DefaultMaxAge of rates of branches.
+	DefaultMaxAge = 24 * time.Hour
 
 	// City.
 	Barnaul         City = "barnaul"
@@ -55,11 +65,20 @@ const (
 var (
 	// Debug mode. Default: false.
 	Debug bool
+
+	// Currencies supported by the client.
+	Currencies = []Currency{USD, EUR, CNY}
+
+	// ErrNoMatch is returned when the page is loaded, but no branches are found on it by any strategy.
+	ErrNoMatch = errors.New("page is loaded, but nothing matched")
 )
 
 // Client.
 type Client struct {
+	ctx       context.Context
 	city      City
+	currency  Currency
+	maxAge    time.Duration
 	buildURL  func() string
 	collector *colly.Collector
 }
@@ -69,49 +88,91 @@ func NewClient() *Client {
 	c := &Client{}
 
 	c.city = Moscow
+	c.currency = USD
+	c.maxAge = DefaultMaxAge
 	c.buildURL = func() string {
-		return fmt.Sprintf(baseURL, c.city)
+		if c.currency == USD {
+			return fmt.Sprintf(baseURL, c.city)
+		}
+
+		return fmt.Sprintf(currencyURL, strings.ToLower(string(c.currency)), c.city)
 	}
 	c.collector = colly.NewCollector(colly.AllowURLRevisit())
 
 	t := &http.Transport{}
 	t.RegisterProtocol("file", http.NewFileTransport(http.Dir("/")))
 
-	c.collector.WithTransport(t)
+	c.ctx = context.Background()
+	c.collector.WithTransport(&ctxTransport{c, t})
 	extensions.RandomUserAgent(c.collector)
 
 	return c
 }
 
-// Rates USDRUB by and city (Moscow, if empty).
-func (c *Client) Rates(ct City) (*Branches, error) {
+// WithContext sets context of the client requests.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	c.ctx = ctx
+	return c
+}
+
+// WithMaxAge sets freshness window of rates, branches updated earlier are returned as stale.
+func (c *Client) WithMaxAge(d time.Duration) *Client {
+	c.maxAge = d
+	return c
+}
+
+// ctxTransport is a transport that makes requests with the client context.
+type ctxTransport struct {
+	c    *Client
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *ctxTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(r.WithContext(t.c.ctx))
+}
+
+// Rates of currency cur (USD, if empty) to RUB by city (Moscow, if empty).
+func (c *Client) Rates(ct City, cur Currency) (*Branches, error) {
 	if len(ct) > 0 {
 		c.city = ct
 	}
 
+	if len(cur) > 0 {
+		if !cur.Supported() {
+			return nil, fmt.Errorf("unsupported currency: %s", cur)
+		}
+
+		c.currency = cur
+	}
+
 	if Debug {
 		log.Printf("[DEBUG] Fetching the currency rate from %s", c.buildURL())
 	}
 
-	r := &Branches{Currency: currency, City: ct}
-	b, err := c.parseBranches()
+	r := &Branches{Currency: c.currency, City: ct}
+	err := c.parseBranches(r)
+	if err == nil && len(r.Items) == 0 && len(r.Stale) == 0 {
+		err = fmt.Errorf("%w: %d rows", ErrNoMatch, r.Rows)
+	}
+
 	if err != nil {
-		r = nil
-	} else {
-		r.Items = b
+		return nil, err
 	}
 
 	if Debug {
-		log.Printf("[DEBUG] Found %d branches", len(b))
+		log.Printf("[DEBUG] Found %d branches of %d rows by %q strategy, %d duplicates, %d stale",
+			len(r.Items), r.Rows, r.Strategy, r.Duplicates, len(r.Stale))
 	}
 
-	return r, err
+	return r, nil
 }
 
-// parseBranches parses branches info.
-func (c *Client) parseBranches() ([]Branch, error) {
+// parseBranches parses branches info of the page into r.
+// Branches are parsed by the strategy that finds most of them, and ones older than max age are stale.
+// Only the first page is read: banki.ru loads the rest of branches by script, which isn't supported.
+func (c *Client) parseBranches(r *Branches) error {
 	var b []Branch
-	var err error
 
 	c.collector.OnRequest(func(r *colly.Request) {
 		if Debug {
@@ -123,31 +184,70 @@ func (c *Client) parseBranches() ([]Branch, error) {
 		log.Println(err)
 	})
 
-	c.collector.OnHTML(".fdpae", func(e *colly.HTMLElement) {
-		e.ForEach(".cITBmP", func(i int, row *colly.HTMLElement) {
-			raw, err := parseBranch(row)
-			if raw != (Branch{}) && err == nil {
-				b = append(b, raw)
-			}
-		})
+	c.collector.OnHTML("html", func(e *colly.HTMLElement) {
+		b, r.Strategy, r.Rows = parse(e, c.currency)
 	})
 
-	err = c.collector.Visit(c.buildURL())
-	if err != nil {
+	if err := c.collector.Visit(c.buildURL()); err != nil {
 		log.Printf("Error visiting page %v", err)
+		return err
+	}
+
+	seen := map[string]bool{}
+	for _, v := range b {
+		k := branchKey(v)
+		if seen[k] {
+			r.Duplicates++
+			continue
+		}
+
+		seen[k] = true
+
+		if age := time.Since(v.Updated); age > c.maxAge {
+			r.Stale = append(r.Stale, Stale{v, fmt.Sprintf("updated %v ago, older than %v",
+				age.Truncate(time.Minute), c.maxAge)})
+			continue
+		}
+
+		r.Items = append(r.Items, v)
 	}
 
-	return b, err
+	return nil
+}
+
+// branchKey identifies branch by bank, address, subways and coordinates.
+func branchKey(b Branch) string {
+	return strings.ToLower(fmt.Sprintf("%s|%s|%s|%.5f|%.5f", b.Bank, b.Address, b.Subway, b.Latitude, b.Longitude))
 }
 
-// parseBranch parses branch info from the HTML element.
-func parseBranch(e *colly.HTMLElement) (Branch, error) {
+// parseBranch parses branch info of currency cur from the HTML element by hashed CSS classes.
+func parseBranch(e *colly.HTMLElement, cur Currency) (Branch, error) {
 	sUpdatedDate := sanitaze(e.ChildText(".hDxmZl"))
 	if len(sUpdatedDate) == 0 {
 		return Branch{}, fmt.Errorf("can't find element .hDxmZl")
 	}
 
-	s := strings.Split(sUpdatedDate, " ")
+	sRates := sanitaze(e.ChildText(".jzaqdw"))
+	if len(sRates) == 0 {
+		return Branch{}, fmt.Errorf("can't find element .jzaqdw")
+	}
+
+	var sBuyRate, sSellRate string
+	if s := strings.Split(sRates, "₽"); len(s) >= 2 {
+		sBuyRate = s[0]
+		sSellRate = s[1]
+	}
+
+	bank := sanitaze(e.ChildText(".gfTHqP"))
+	subway := sanitaze(e.ChildText(".dJGHYE"))
+
+	return newBranchFromText(cur, bank, subway, sBuyRate, sSellRate, sUpdatedDate)
+}
+
+// newBranchFromText creates a new Branch of currency cur from texts of rates and updated date, e.g. "87,25 ₽" and
+// "Обновление: 03.07.2023 16:23", and validates it.
+func newBranchFromText(cur Currency, bank, subway, sBuyRate, sSellRate, sUpdatedDate string) (Branch, error) {
+	s := strings.Split(strings.TrimSpace(sUpdatedDate), " ")
 	if count := len(s); count >= 3 {
 		sUpdatedDate = strings.Join(s[count-2:], " ")
 	}
@@ -162,41 +262,38 @@ func parseBranch(e *colly.HTMLElement) (Branch, error) {
 		return Branch{}, err
 	}
 
-	if time.Now().In(loc).Sub(updatedDate) > 24*time.Hour {
-		return Branch{}, fmt.Errorf("exchange rate is out of date for 24 hours: %v", updatedDate)
-	}
-
-	sRates := sanitaze(e.ChildText(".jzaqdw"))
-	if len(sRates) == 0 {
-		return Branch{}, fmt.Errorf("can't find element .jzaqdw")
-	}
-
-	var sBuyRate, sSellRate string
-	if s := strings.Split(sRates, "₽"); len(s) >= 2 {
-		sBuyRate = s[0]
-		sSellRate = s[1]
+	buyRate, err := parseRate(sBuyRate)
+	if err != nil {
+		return Branch{}, err
 	}
 
-	sBuyRate = strings.Replace(sBuyRate, " ", "", -1)
-	buyRate, err := strconv.ParseFloat(strings.ReplaceAll(sBuyRate, ",", "."), 64)
+	sellRate, err := parseRate(sSellRate)
 	if err != nil {
 		return Branch{}, err
 	}
 
-	if buyRate <= 0 {
-		return Branch{}, fmt.Errorf("buy rate is zero or less: %v", buyRate)
-	}
+	return validBranch(newBranch(bank, subway, string(cur), buyRate, sellRate, updatedDate))
+}
 
-	sSellRate = strings.Replace(sSellRate, " ", "", -1)
-	sellRate, err := strconv.ParseFloat(strings.ReplaceAll(sSellRate, ",", "."), 64)
-	if err != nil {
-		return Branch{}, err
+// parseRate parses rate text, e.g. "87,25 ₽".
+func parseRate(s string) (float64, error) {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "₽"))
+	s = strings.Replace(s, " ", "", -1)
+
+	return strconv.ParseFloat(strings.ReplaceAll(s, ",", "."), 64)
+}
+
+// validBranch returns branch if its rates are positive.
+func validBranch(b Branch) (Branch, error) {
+	if b.Buy <= 0 {
+		return Branch{}, fmt.Errorf("buy rate is zero or less: %v", b.Buy)
 	}
 
-	bank := sanitaze(e.ChildText(".gfTHqP"))
-	subway := sanitaze(e.ChildText(".dJGHYE"))
+	if b.Sell <= 0 {
+		return Branch{}, fmt.Errorf("sell rate is zero or less: %v", b.Sell)
+	}
 
-	return newBranch(bank, subway, currency, buyRate, sellRate, updatedDate), nil
+	return b, nil
 }
 
 // sanitaize string.
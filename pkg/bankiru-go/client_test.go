@@ -1,11 +1,16 @@
 package bankiru
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestClient_Rates(t *testing.T) {
@@ -15,7 +20,7 @@ func TestClient_Rates(t *testing.T) {
 		return "file:" + filepath.Join(dir, "/test/bankiru")
 	}
 
-	r, err := c.Rates(Novosibirsk)
+	r, err := c.Rates(Novosibirsk, "")
 	if err != nil {
 		t.Error(err)
 	}
@@ -29,6 +34,28 @@ func TestClient_Rates(t *testing.T) {
 		t.Errorf("bCount got = %v, want %v", bCount, 4)
 	}
 
+	if r.Strategy != Semantic {
+		t.Errorf("Strategy got = %v, want %v", r.Strategy, Semantic)
+	}
+
+	if r.Rows != 12 {
+		t.Errorf("Rows got = %v, want %v", r.Rows, 12)
+	}
+
+	// Outdated row is a copy of a fresh one
+	if r.Duplicates != 1 || len(r.Stale) != 0 {
+		t.Errorf("Duplicates, Stale got = %v, %v, want 1, none", r.Duplicates, r.Stale)
+	}
+
+	if want := "Площадь Ленина, Красный проспект, Площадь Гарина-Михайловского"; b[1].Subway != want {
+		t.Errorf("Subway got = %q, want %q", b[1].Subway, want)
+	}
+
+	// List page has no details
+	if d := b[1]; d.HasLocation() || len(d.Address) > 0 || len(d.Phone) > 0 || len(d.Hours) > 0 {
+		t.Errorf("details of %v, want none", d)
+	}
+
 	// Error
 	Debug = true
 
@@ -38,11 +65,109 @@ func TestClient_Rates(t *testing.T) {
 		return "file:" + filepath.Join(dir, "/test/invalid-bankiru")
 	}
 
-	if _, err := c.Rates(Sochi); err == nil {
+	if _, err := c.Rates(Sochi, ""); err == nil {
 		t.Error(err)
 	}
 }
 
+func TestClient_Rates_strategies(t *testing.T) {
+	tests := []struct {
+		file     string
+		strategy Strategy
+		count    int
+	}{
+		{"bankiru-classes", Classes, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			c := NewClient()
+			c.buildURL = func() string {
+				dir, _ := os.Getwd()
+				return "file:" + filepath.Join(dir, "test", tt.file)
+			}
+
+			r, err := c.Rates(Novosibirsk, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if r.Strategy != tt.strategy {
+				t.Errorf("Strategy got = %v, want %v", r.Strategy, tt.strategy)
+			}
+
+			if got := len(r.Items); got != tt.count {
+				t.Errorf("count got = %v, want %v", got, tt.count)
+			}
+		})
+	}
+}
+
+func TestClient_Rates_noMatch(t *testing.T) {
+	c := NewClient()
+	c.buildURL = func() string {
+		dir, _ := os.Getwd()
+		return "file:" + filepath.Join(dir, "/test/bankiru-redesign")
+	}
+
+	if _, err := c.Rates(Moscow, ""); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("error = %v, want %v", err, ErrNoMatch)
+	}
+}
+
+func TestClient_Rates_currency(t *testing.T) {
+	c := NewClient()
+	c.buildURL = func() string {
+		dir, _ := os.Getwd()
+		return "file:" + filepath.Join(dir, "/test/bankiru-classes")
+	}
+
+	r, err := c.Rates(Novosibirsk, EUR)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.Items) != 1 || r.Currency != EUR || r.Items[0].Currency != "EUR" {
+		t.Errorf("Rates() = %v, want 1 EUR branch", r)
+	}
+
+	if _, err := NewClient().Rates(Moscow, "XYZ"); err == nil {
+		t.Error("error is nil, want unsupported currency")
+	}
+}
+
+func TestClient_WithMaxAge(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		loc, _ := time.LoadLocation("Europe/Moscow")
+		row := `<div class="cITBmP"><div class="gfTHqP">%s</div><div class="jzaqdw">86,50 ₽ 89,90 ₽</div>` +
+			`<div class="hDxmZl">Обновление: %s</div></div>`
+
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><div class="fdpae">`+row+row+`</div></html>`,
+			"fresh", time.Now().Add(-time.Hour).In(loc).Format("02.01.2006 15:04"),
+			"stale", time.Now().Add(-5*time.Hour).In(loc).Format("02.01.2006 15:04"))
+	}))
+	defer srv.Close()
+
+	c := NewClient().WithMaxAge(3 * time.Hour)
+	c.buildURL = func() string {
+		return srv.URL
+	}
+
+	r, err := c.Rates(Moscow, USD)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.Items) != 1 || r.Items[0].Bank != "fresh" {
+		t.Errorf("Items got = %v, want fresh branch", r.Items)
+	}
+
+	if len(r.Stale) != 1 || r.Stale[0].Branch.Bank != "stale" || !strings.Contains(r.Stale[0].Reason, "older than 3h0m0s") {
+		t.Errorf("Stale got = %v, want stale branch", r.Stale)
+	}
+}
+
 func Test_buildURL(t *testing.T) {
 	buildURL := func() string {
 		return fmt.Sprintf(baseURL, strings.ToLower(string(Moscow)))
@@ -53,4 +178,27 @@ func Test_buildURL(t *testing.T) {
 	if got := buildURL(); got != want {
 		t.Errorf("URL.build() = %v, want %v", got, want)
 	}
+
+	c := NewClient()
+	c.currency = CNY
+	if got, want := c.buildURL(), "https://www.banki.ru/products/currency/map/cny/moskva/"; got != want {
+		t.Errorf("URL.build() = %v, want %v", got, want)
+	}
+}
+
+func TestClient_WithContext(t *testing.T) {
+	srv := httptest.NewServer(http.FileServer(http.Dir("test")))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c := NewClient().WithContext(ctx)
+	c.buildURL = func() string {
+		return srv.URL + "/bankiru"
+	}
+
+	if _, err := c.Rates(Novosibirsk, ""); err == nil {
+		t.Error("error is nil, want context canceled")
+	}
 }
@@ -0,0 +1,133 @@
+package bankiru
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly/v2"
+)
+
+// Strategy of parsing branches.
+// The page has no embedded JSON state with branches, so only its markup is parsed.
+type Strategy string
+
+const (
+	// Semantic parses the page markup by component names, attributes and labels.
+	Semantic Strategy = "semantic"
+	// Classes parses the page markup by hashed CSS classes, which change on every redeploy of the site.
+	Classes Strategy = "classes"
+)
+
+// strategies in order of preference.
+var strategies = []struct {
+	name  Strategy
+	parse func(e *colly.HTMLElement, cur Currency) ([]Branch, int)
+}{
+	{Semantic, parseSemantic},
+	{Classes, parseClasses},
+}
+
+// parse branches of currency cur of the page by the strategy that finds most of them, the preferred one of equals.
+// It returns the number of rows found by the strategy, including invalid ones, or the maximum of them
+// if no strategy finds any branches.
+func parse(e *colly.HTMLElement, cur Currency) ([]Branch, Strategy, int) {
+	var best []Branch
+	var name Strategy
+	var rows, max int
+	for _, s := range strategies {
+		b, n := s.parse(e, cur)
+		if n > max {
+			max = n
+		}
+
+		if Debug {
+			log.Printf("[DEBUG] Found %d branches by %q strategy of %d rows", len(b), s.name, n)
+		}
+
+		if len(b) > len(best) {
+			best, name, rows = b, s.name, n
+		}
+	}
+
+	if len(best) == 0 {
+		return nil, "", max
+	}
+
+	return best, name, rows
+}
+
+// parseClasses parses branches by hashed CSS classes.
+func parseClasses(e *colly.HTMLElement, cur Currency) ([]Branch, int) {
+	var b []Branch
+	var rows int
+
+	e.ForEach(".fdpae .cITBmP", func(i int, row *colly.HTMLElement) {
+		rows++
+		if v, err := parseBranch(row, cur); err == nil {
+			b = append(b, v)
+		}
+	})
+
+	return b, rows
+}
+
+// parseSemantic parses branches by styled component names, data attributes and text labels.
+func parseSemantic(e *colly.HTMLElement, cur Currency) ([]Branch, int) {
+	var b []Branch
+	var rows int
+
+	e.ForEach(`[class*="StyledWrapperResult"]`, func(i int, row *colly.HTMLElement) {
+		rows++
+		if v, err := parseSemanticBranch(row, cur); err == nil {
+			b = append(b, v)
+		}
+	})
+
+	return b, rows
+}
+
+// parseSemanticBranch parses branch info from the row element by component names, data attributes and text labels.
+func parseSemanticBranch(row *colly.HTMLElement, cur Currency) (Branch, error) {
+	texts := row.DOM.Find(`[data-test="text"]`)
+
+	// Rate is the last text next to its label.
+	rate := func(label string) string {
+		var s string
+		texts.EachWithBreak(func(i int, t *goquery.Selection) bool {
+			if strings.TrimSpace(t.Text()) != label {
+				return true
+			}
+
+			s = sanitaze(t.Parent().Find(`[data-test="text"]`).Last().Text())
+			return false
+		})
+
+		return s
+	}
+
+	var updated string
+	texts.EachWithBreak(func(i int, t *goquery.Selection) bool {
+		if s := sanitaze(t.Text()); strings.HasPrefix(strings.TrimSpace(s), "Обновление") {
+			updated = s
+			return false
+		}
+
+		return true
+	})
+
+	// Header is the first row of the item with bank name, subways and logo.
+	header := row.DOM.ChildrenFiltered(`[direction="row"]`).First()
+	names := header.Find(`[data-test="text"]`)
+
+	bank := strings.TrimSpace(header.Find("img[alt]").AttrOr("alt", ""))
+	if len(bank) == 0 {
+		bank = strings.TrimSpace(sanitaze(names.First().Text()))
+	}
+
+	subway := ""
+	if names.Length() > 1 {
+		subway = sanitaze(names.Eq(1).Text())
+	}
+
+	return newBranchFromText(cur, bank, subway, rate("Покупка"), rate("Продажа"), updated)
+}
@@ -0,0 +1,81 @@
+package bankiru
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly/v2"
+)
+
+func Test_parseRate(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    float64
+		wantErr bool
+	}{
+		{"87,25 ₽", 87.25, false},
+		{" 1 087,25 ", 1087.25, false},
+		{"89,,90 ₽", 0, true},
+		{" -", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseRate(tt.s)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseRate(%q) = %v, %v, want %v, error %v", tt.s, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func Test_parse(t *testing.T) {
+	// Both strategies find the same branches of the recorded page, so the preferred one is used
+	b, s, rows := parse(element(t, "bankiru"), USD)
+	if len(b) != 5 || s != Semantic || rows != 12 {
+		t.Errorf("parse() = %d branches by %q of %d rows, want 5 by %q of 12", len(b), s, rows, Semantic)
+	}
+
+	// Strategy with more branches wins over the preferred one
+	row := `<div class="cITBmP"><div class="gfTHqP">Банк</div><div class="jzaqdw">87,25 ₽ 89,25 ₽</div>` +
+		`<div class="hDxmZl">Обновление: 03.07.2123 16:23</div></div>`
+	html := page(t, "bankiru") + `<div class="fdpae">` + row + `</div>`
+
+	b, s, rows = parse(htmlElement(t, html), USD)
+	if len(b) != 6 || s != Classes || rows != 13 {
+		t.Errorf("parse() = %d branches by %q of %d rows, want 6 by %q of 13", len(b), s, rows, Classes)
+	}
+
+	// No branches
+	b, s, rows = parse(element(t, "bankiru-redesign"), USD)
+	if len(b) != 0 || len(s) != 0 {
+		t.Errorf("parse() = %d branches by %q of %d rows, want none", len(b), s, rows)
+	}
+}
+
+// page of test file.
+func page(t *testing.T, file string) string {
+	b, err := os.ReadFile(filepath.Join("test", file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(b)
+}
+
+// element of the whole page of test file.
+func element(t *testing.T, file string) *colly.HTMLElement {
+	return htmlElement(t, page(t, file))
+}
+
+// htmlElement of the whole html document.
+func htmlElement(t *testing.T, html string) *colly.HTMLElement {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return colly.NewHTMLElementFromSelectionNode(&colly.Response{}, doc.Selection, doc.Selection.Nodes[0], 0)
+}
@@ -20,3 +20,34 @@ func (r *Rate) String() string {
 
 	return string(b)
 }
+
+// Offer of an exchanger.
+type Offer struct {
+	Exchanger  string   `json:"exchanger"`
+	Rate       float64  `json:"rate"`             // Amount of cash currency per 1 of cryptocurrency, as the table shows it.
+	Reserve    float64  `json:"reserve"`          // Reserve of the received currency.
+	Min        float64  `json:"min,omitempty"`    // Minimum amount of the given currency, 0 means no limit.
+	Max        float64  `json:"max,omitempty"`    // Maximum amount of the given currency, 0 means no limit.
+	Reviews    int      `json:"reviews"`          // Number of positive reviews.
+	Complaints int      `json:"complaints"`       // Number of negative reviews.
+	Badges     []string `json:"badges,omitempty"` // E.g. Manual, Verification.
+}
+
+// Offers of exchangers, best first.
+type Offers struct {
+	Direction Direction `json:"direction"`
+	Average   float64   `json:"average"`
+	Items     []Offer   `json:"items"`
+	Rows      int       `json:"rows"` // Rows of the offer table, including invalid ones.
+}
+
+// String representation of offers.
+func (o *Offers) String() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		fmt.Println(err)
+		return ""
+	}
+
+	return string(b)
+}
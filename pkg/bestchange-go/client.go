@@ -1,25 +1,36 @@
 package bestchange
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"regexp"
 	"strconv"
+	"strings"
 
+	"github.com/PuerkitoBio/goquery"
 	"github.com/gocolly/colly/v2"
 	"github.com/gocolly/colly/v2/extensions"
 )
 
 const (
 	// Example: https://www.bestchange.com/cash-ruble-to-tether-trc20-in-msk.html.
-	baseURL = "https://www.bestchange.com/cash-ruble-to-tether-trc20-in-msk.html"
+	baseURL = "https://www.bestchange.com"
 )
 
 var (
 	// Debug mode. Default: false.
 	Debug bool
+
+	// ErrNoMatch is returned when the page is loaded, but the rate isn't found on it.
+	ErrNoMatch = errors.New("page is loaded, but nothing matched")
 )
 
 // Client.
 type Client struct {
+	ctx       context.Context
+	direction Direction
 	buildURL  func() string
 	collector *colly.Collector
 }
@@ -29,50 +40,68 @@ func NewClient() *Client {
 	c := &Client{}
 	c.collector = colly.NewCollector(colly.AllowURLRevisit())
 
+	c.direction = DefaultDirection
 	c.buildURL = func() string {
-		return baseURL
+		return c.direction.URL()
 	}
 
 	t := &http.Transport{}
 	t.RegisterProtocol("file", http.NewFileTransport(http.Dir("/")))
 
-	c.collector.WithTransport(t)
+	c.ctx = context.Background()
+	c.collector.WithTransport(&ctxTransport{c, t})
 	extensions.RandomUserAgent(c.collector)
 
 	return c
 }
 
-// Rate in Moscow.
+// WithContext sets context of the client requests.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	c.ctx = ctx
+	return c
+}
+
+// WithDirection sets direction of exchange.
+func (c *Client) WithDirection(d Direction) *Client {
+	c.direction = d
+	return c
+}
+
+// ctxTransport is a transport that makes requests with the client context.
+type ctxTransport struct {
+	c    *Client
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *ctxTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(r.WithContext(t.c.ctx))
+}
+
+// Rate of the direction.
 func (c *Client) Rate() (float64, error) {
 	if Debug {
-		log.Printf("[DEBUG] Fetching the USDT (TRC20) rate from %s", c.buildURL())
+		log.Printf("[DEBUG] Fetching the %s rate from %s", c.direction.Asset().Title(), c.buildURL())
 	}
 
 	r := &Rate{}
 	b, err := c.parseRate()
 	if err != nil {
-		r = nil
-	} else {
-		r.Value = b
+		return 0, err
 	}
 
-	return r.Value, err
+	r.Value = b
+
+	return r.Value, nil
 }
 
 // parseRate parses rate.
 func (c *Client) parseRate() (float64, error) {
 	var v float64
-	var err error
+	var matched bool
+	var err, parseErr error
 
-	c.collector.OnRequest(func(r *colly.Request) {
-		if Debug {
-			log.Printf("UserAgent: %s", r.Headers.Get("User-Agent"))
-		}
-	})
-
-	c.collector.OnError(func(r *colly.Response, err error) {
-		log.Println(err)
-	})
+	c.logRequests()
 
 	c.collector.OnHTML("span[title='Average rate']", func(e *colly.HTMLElement) {
 		s := e.ChildText("span.bt")
@@ -85,13 +114,147 @@ func (c *Client) parseRate() (float64, error) {
 			return
 		}
 
-		v, err = strconv.ParseFloat(s, 64)
+		matched = true
+		v, parseErr = strconv.ParseFloat(s, 64)
 	})
 
 	err = c.collector.Visit(c.buildURL())
 	if err != nil {
 		log.Printf("Error visiting page %v", err)
+		return 0, err
+	}
+
+	if !matched {
+		return 0, ErrNoMatch
+	}
+
+	return v, parseErr
+}
+
+// Offers of exchangers of the direction, best first, with the average rate.
+func (c *Client) Offers() (*Offers, error) {
+	if Debug {
+		log.Printf("[DEBUG] Fetching the %s offers from %s", c.direction.Asset().Title(), c.buildURL())
+	}
+
+	o := &Offers{Direction: c.direction}
+	var avgErr error
+
+	c.logRequests()
+
+	c.collector.OnHTML("span[title='Average rate']", func(e *colly.HTMLElement) {
+		if s := e.ChildText("span.bt"); len(s) > 0 {
+			o.Average, avgErr = strconv.ParseFloat(s, 64)
+		}
+	})
+
+	c.collector.OnHTML("#content_table tbody tr", func(e *colly.HTMLElement) {
+		o.Rows++
+
+		v, err := parseOffer(e, c.direction.Selling())
+		if err != nil {
+			if Debug {
+				log.Printf("[DEBUG] Invalid offer of row %d: %v", o.Rows, err)
+			}
+			return
+		}
+
+		o.Items = append(o.Items, v)
+	})
+
+	if err := c.collector.Visit(c.buildURL()); err != nil {
+		log.Printf("Error visiting page %v", err)
+		return nil, err
 	}
 
-	return v, err
+	if len(o.Items) == 0 {
+		if avgErr != nil {
+			return nil, avgErr
+		}
+
+		if o.Average == 0 {
+			return nil, fmt.Errorf("%w: %d rows", ErrNoMatch, o.Rows)
+		}
+	}
+
+	return o, nil
+}
+
+// logRequests of the collector.
+func (c *Client) logRequests() {
+	c.collector.OnRequest(func(r *colly.Request) {
+		if Debug {
+			log.Printf("UserAgent: %s", r.Headers.Get("User-Agent"))
+		}
+	})
+
+	c.collector.OnError(func(r *colly.Response, err error) {
+		log.Println(err)
+	})
+}
+
+// parseOffer parses offer of the table row, where cryptocurrency is given if selling.
+func parseOffer(e *colly.HTMLElement, selling bool) (Offer, error) {
+	o := Offer{Exchanger: strings.TrimSpace(e.ChildText("td.bj .ca"))}
+	if len(o.Exchanger) == 0 {
+		return Offer{}, errors.New("exchanger is empty")
+	}
+
+	cols := e.DOM.ChildrenFiltered("td.bi")
+	if cols.Length() < 2 {
+		return Offer{}, fmt.Errorf("%d amount columns, want 2", cols.Length())
+	}
+
+	give, err := parseNumber(cols.Eq(0).Find(".fs").Text())
+	if err != nil {
+		return Offer{}, fmt.Errorf("give amount: %w", err)
+	}
+
+	get, err := parseNumber(cols.Eq(1).Text())
+	if err != nil {
+		return Offer{}, fmt.Errorf("get amount: %w", err)
+	}
+
+	if give <= 0 || get <= 0 {
+		return Offer{}, fmt.Errorf("amounts are zero or less: %v, %v", give, get)
+	}
+
+	// Rate is cash per 1 of cryptocurrency, whichever of the amounts is 1,
+	// e.g. 1.02 USDT for 1 cash USD is 0.98 USD
+	if selling {
+		o.Rate = get / give
+	} else {
+		o.Rate = give / get
+	}
+
+	o.Min, _ = parseNumber(cols.Eq(0).Find(".fm1").Text())
+	o.Max, _ = parseNumber(cols.Eq(0).Find(".fm2").Text())
+	o.Reserve, _ = parseNumber(e.ChildText("td.ar"))
+
+	reviews, _ := parseNumber(e.ChildText(".rwpos"))
+	complaints, _ := parseNumber(e.ChildText(".rwneg"))
+	o.Reviews, o.Complaints = int(reviews), int(complaints)
+
+	e.DOM.Find(".lbpl [title]").Each(func(i int, s *goquery.Selection) {
+		if t := strings.TrimSpace(s.AttrOr("title", "")); len(t) > 0 {
+			o.Badges = append(o.Badges, t)
+		}
+	})
+
+	return o, nil
+}
+
+var numberRe = regexp.MustCompile(`\d[\d\s.,]*`)
+
+// parseNumber parses the first number of text, e.g. "from 100 000" or "96,35 Cash RUB".
+func parseNumber(s string) (float64, error) {
+	n := numberRe.FindString(strings.ReplaceAll(s, "\u00a0", " "))
+	if len(n) == 0 {
+		return 0, fmt.Errorf("no number in %q", s)
+	}
+
+	n = strings.Join(strings.Fields(n), "")
+	n = strings.TrimRight(strings.ReplaceAll(n, ",", "."), ".")
+
+	return strconv.ParseFloat(n, 64)
 }
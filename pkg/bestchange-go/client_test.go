@@ -1,8 +1,13 @@
 package bestchange
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -22,16 +27,144 @@ func TestClient_Rate(t *testing.T) {
 	if got != want {
 		t.Errorf("Avg rate = %v, want %v", got, want)
 	}
+
+	// Nothing matched
+	c.buildURL = func() string {
+		dir, _ := os.Getwd()
+		return "file:" + filepath.Join(dir, "/test/bestchangecom-redesign")
+	}
+
+	if _, err := c.Rate(); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("error = %v, want %v", err, ErrNoMatch)
+	}
 }
 
 func Test_buildURL(t *testing.T) {
-	buildURL := func() string {
-		return baseURL
+	want := "https://www.bestchange.com/cash-ruble-to-tether-trc20-in-msk.html"
+	if got := NewClient().buildURL(); got != want {
+		t.Errorf("URL.build() = %v, want %v", got, want)
 	}
 
-	want := (NewClient()).buildURL()
-
-	if got := buildURL(); got != want {
+	want = "https://www.bestchange.com/tether-erc20-to-cash-ruble-in-spb.html"
+	if got := NewClient().WithDirection(Direction{USDTERC20, CashRUB, SaintPetersburg}).buildURL(); got != want {
 		t.Errorf("URL.build() = %v, want %v", got, want)
 	}
 }
+
+func TestClient_WithContext(t *testing.T) {
+	srv := httptest.NewServer(http.FileServer(http.Dir("test")))
+	defer srv.Close()
+
+	c := NewClient()
+	c.buildURL = func() string {
+		return srv.URL + "/bestchangecom"
+	}
+
+	got, err := c.WithContext(context.Background()).Rate()
+	if err != nil {
+		t.Error(err)
+	}
+
+	if want := 96.414084; got != want {
+		t.Errorf("Avg rate = %v, want %v", got, want)
+	}
+
+	// Canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := c.WithContext(ctx).Rate(); err == nil {
+		t.Error("error is nil, want context canceled")
+	}
+}
+
+func TestClient_Offers(t *testing.T) {
+	c := NewClient()
+	c.buildURL = func() string {
+		dir, _ := os.Getwd()
+		return "file:" + filepath.Join(dir, "/test/bestchangecom-offers")
+	}
+
+	got, err := c.Offers()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := 96.414084; got.Average != want {
+		t.Errorf("Average = %v, want %v", got.Average, want)
+	}
+
+	if got.Rows != 4 {
+		t.Errorf("Rows = %v, want %v", got.Rows, 4)
+	}
+
+	if len(got.Items) != 3 {
+		t.Fatalf("Items = %v, want 3 offers", got.Items)
+	}
+
+	want := Offer{Exchanger: "Obmen-Market", Rate: 95.8, Reserve: 1234567.89, Min: 100000, Max: 3000000,
+		Reviews: 2345, Complaints: 0, Badges: []string{"Manual", "Verification"}}
+	if !reflect.DeepEqual(got.Items[0], want) {
+		t.Errorf("Items[0] = %+v, want %+v", got.Items[0], want)
+	}
+
+	if o := got.Items[1]; o.Max != 0 || o.Complaints != 2 || len(o.Badges) != 0 {
+		t.Errorf("Items[1] = %+v, want no max, 2 complaints and no badges", o)
+	}
+
+	if o := got.Items[2]; o.Rate != 96.35 {
+		t.Errorf("Items[2].Rate = %v, want %v", o.Rate, 96.35)
+	}
+
+	// Rate is cash per 1 of cryptocurrency given
+	c = NewClient().WithDirection(Direction{Give: USDTTRC20, Get: CashUSD, City: Moscow})
+	c.buildURL = func() string {
+		dir, _ := os.Getwd()
+		return "file:" + filepath.Join(dir, "/test/bestchangecom-offers")
+	}
+
+	if got, err = c.Offers(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := 1 / 95.8; got.Items[0].Rate != want {
+		t.Errorf("Items[0].Rate = %v, want %v", got.Items[0].Rate, want)
+	}
+
+	// Nothing matched
+	c = NewClient()
+	c.buildURL = func() string {
+		dir, _ := os.Getwd()
+		return "file:" + filepath.Join(dir, "/test/bestchangecom-redesign")
+	}
+
+	if _, err := c.Offers(); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("error = %v, want %v", err, ErrNoMatch)
+	}
+}
+
+func Test_parseNumber(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    float64
+		wantErr bool
+	}{
+		{"96.414084", 96.414084, false},
+		{"96,35 Cash RUB", 96.35, false},
+		{"from 100 000", 100000, false},
+		{"1 234 567.89", 1234567.89, false},
+		{"1 Tether TRC20 USDT", 1, false},
+		{"Cash RUB", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseNumber(tt.s)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseNumber(%q) error = %v, wantErr %v", tt.s, err, tt.wantErr)
+		}
+
+		if got != tt.want {
+			t.Errorf("parseNumber(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
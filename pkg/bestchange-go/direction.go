@@ -0,0 +1,177 @@
+package bestchange
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Currency of exchange by its BestChange name.
+type Currency string
+
+const (
+	CashRUB   Currency = "cash-ruble"
+	CashUSD   Currency = "cash-dollar"
+	USDTTRC20 Currency = "tether-trc20"
+	USDTERC20 Currency = "tether-erc20"
+	USDTBEP20 Currency = "tether-bep20"
+	BTC       Currency = "bitcoin"
+)
+
+// currencies by name with their codes and networks.
+var currencies = map[Currency]struct {
+	code    string
+	network string
+	crypto  bool
+}{
+	CashRUB:   {"RUB", "", false},
+	CashUSD:   {"USD", "", false},
+	USDTTRC20: {"USDT", "TRC20", true},
+	USDTERC20: {"USDT", "ERC20", true},
+	USDTBEP20: {"USDT", "BEP20", true},
+	BTC:       {"BTC", "", true},
+}
+
+// Supported reports whether the currency is supported.
+func (c Currency) Supported() bool {
+	_, ok := currencies[c]
+	return ok
+}
+
+// Code of the currency, e.g. USDT.
+func (c Currency) Code() string {
+	return currencies[c].code
+}
+
+// Network of the currency, e.g. TRC20, or empty one.
+func (c Currency) Network() string {
+	return currencies[c].network
+}
+
+// Crypto reports whether the currency is a cryptocurrency.
+func (c Currency) Crypto() bool {
+	return currencies[c].crypto
+}
+
+// Title of the currency, e.g. USDT (TRC20) or cash RUB.
+func (c Currency) Title() string {
+	v := currencies[c]
+	switch {
+	case len(v.network) > 0:
+		return fmt.Sprintf("%s (%s)", v.code, v.network)
+	case !v.crypto:
+		return "cash " + v.code
+	default:
+		return v.code
+	}
+}
+
+// City of exchangers by its BestChange name.
+type City string
+
+const (
+	Moscow          City = "msk"
+	SaintPetersburg City = "spb"
+	Yekaterinburg   City = "ekb"
+	Novosibirsk     City = "nsk"
+	Kazan           City = "kazan"
+)
+
+// cities by name with their titles.
+var cities = map[City]string{
+	Moscow:          "Moscow",
+	SaintPetersburg: "Saint Petersburg",
+	Yekaterinburg:   "Yekaterinburg",
+	Novosibirsk:     "Novosibirsk",
+	Kazan:           "Kazan",
+}
+
+// Supported reports whether the city is supported.
+func (c City) Supported() bool {
+	_, ok := cities[c]
+	return ok
+}
+
+// Title of the city, e.g. Moscow.
+func (c City) Title() string {
+	return cities[c]
+}
+
+// Direction of exchange of the given currency to the received one in the city.
+type Direction struct {
+	Give Currency `json:"give"`
+	Get  Currency `json:"get"`
+	City City     `json:"city"`
+}
+
+// DefaultDirection is buying USDT (TRC20) for cash RUB in Moscow.
+var DefaultDirection = Direction{CashRUB, USDTTRC20, Moscow}
+
+// ParseDirection parses direction of its BestChange page name, e.g. cash-ruble-to-tether-trc20-in-msk.
+func ParseDirection(s string) (Direction, error) {
+	pair, city, ok := strings.Cut(strings.TrimSuffix(s, ".html"), "-in-")
+	if !ok {
+		return Direction{}, fmt.Errorf("invalid direction %q, want e.g. %s", s, DefaultDirection)
+	}
+
+	give, get, ok := strings.Cut(pair, "-to-")
+	if !ok {
+		return Direction{}, fmt.Errorf("invalid direction %q, want e.g. %s", s, DefaultDirection)
+	}
+
+	d := Direction{Currency(give), Currency(get), City(city)}
+
+	return d, d.Validate()
+}
+
+// Validate reports an error if the currencies or the city aren't supported,
+// or no cryptocurrency is exchanged.
+func (d Direction) Validate() error {
+	for _, c := range []Currency{d.Give, d.Get} {
+		if !c.Supported() {
+			return fmt.Errorf("unsupported currency %q", c)
+		}
+	}
+
+	if !d.City.Supported() {
+		return fmt.Errorf("unsupported city %q", d.City)
+	}
+
+	if d.Give.Crypto() == d.Get.Crypto() {
+		return fmt.Errorf("direction %s must exchange cash for cryptocurrency or vice versa", d)
+	}
+
+	return nil
+}
+
+// Selling reports whether cryptocurrency is given.
+func (d Direction) Selling() bool {
+	return d.Give.Crypto()
+}
+
+// Asset is the exchanged cryptocurrency.
+func (d Direction) Asset() Currency {
+	if d.Selling() {
+		return d.Give
+	}
+
+	return d.Get
+}
+
+// Quote is the cash currency of the rate.
+func (d Direction) Quote() Currency {
+	if d.Selling() {
+		return d.Get
+	}
+
+	return d.Give
+}
+
+// String is the BestChange page name of the direction.
+func (d Direction) String() string {
+	return fmt.Sprintf("%s-to-%s-in-%s", d.Give, d.Get, d.City)
+}
+
+// URL of the direction page.
+func (d Direction) URL() string {
+	return fmt.Sprintf("%s/%s.html", baseURL, d)
+}
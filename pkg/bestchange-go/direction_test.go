@@ -0,0 +1,54 @@
+package bestchange
+
+import "testing"
+
+func TestParseDirection(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    Direction
+		wantErr bool
+	}{
+		{"cash-ruble-to-tether-trc20-in-msk", DefaultDirection, false},
+		{"tether-bep20-to-cash-ruble-in-spb.html", Direction{USDTBEP20, CashRUB, SaintPetersburg}, false},
+		{"cash-dollar-to-bitcoin-in-kazan", Direction{CashUSD, BTC, Kazan}, false},
+		{"cash-ruble-to-tether-trc20", Direction{}, true},
+		{"cash-ruble-tether-trc20-in-msk", Direction{}, true},
+		{"cash-ruble-to-dogecoin-in-msk", Direction{CashRUB, "dogecoin", Moscow}, true},
+		{"cash-ruble-to-tether-trc20-in-mars", Direction{CashRUB, USDTTRC20, "mars"}, true},
+		{"cash-ruble-to-cash-dollar-in-msk", Direction{CashRUB, CashUSD, Moscow}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDirection(tt.s)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDirection(%q) error = %v, wantErr %v", tt.s, err, tt.wantErr)
+		}
+
+		if got != tt.want {
+			t.Errorf("ParseDirection(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestDirection(t *testing.T) {
+	d := Direction{USDTTRC20, CashRUB, SaintPetersburg}
+	if !d.Selling() || d.Asset() != USDTTRC20 || d.Quote() != CashRUB {
+		t.Errorf("Selling, Asset, Quote = %v, %v, %v, want true, %v, %v", d.Selling(), d.Asset(), d.Quote(), USDTTRC20, CashRUB)
+	}
+
+	if got, want := d.Asset().Title(), "USDT (TRC20)"; got != want {
+		t.Errorf("Title = %v, want %v", got, want)
+	}
+
+	if got, want := d.Quote().Title(), "cash RUB"; got != want {
+		t.Errorf("Title = %v, want %v", got, want)
+	}
+
+	if got, want := BTC.Title(), "BTC"; got != want {
+		t.Errorf("Title = %v, want %v", got, want)
+	}
+
+	if DefaultDirection.Selling() || DefaultDirection.Asset() != USDTTRC20 {
+		t.Errorf("DefaultDirection is selling or asset is %v", DefaultDirection.Asset())
+	}
+}
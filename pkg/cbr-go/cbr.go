@@ -2,6 +2,7 @@ package cbr
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
 	"fmt"
 	"io"
@@ -16,6 +17,7 @@ import (
 const (
 	baseURL    = "http://www.cbr.ru/scripts/XML_daily_eng.asp"
 	dateFormat = "02/01/2006"
+	resultDate = "02.01.2006"
 )
 
 // Debug mode.
@@ -45,12 +47,19 @@ type httpClientInterface interface {
 
 // Client is a rates service client.
 type Client struct {
+	ctx        context.Context
 	httpClient httpClientInterface
 }
 
 // NewClient creates a new rates service instance.
 func NewClient() *Client {
-	return &Client{httpClient: &http.Client{}}
+	return &Client{ctx: context.Background(), httpClient: &http.Client{}}
+}
+
+// WithContext sets context of the client requests.
+func (s *Client) WithContext(ctx context.Context) *Client {
+	s.ctx = ctx
+	return s
 }
 
 // GetRate returns a currency rate for a given currency and date.
@@ -62,6 +71,33 @@ func (s *Client) GetRate(currency string, t time.Time) (float64, error) {
 	return rate, nil
 }
 
+// GetRateDate returns a currency rate for a given date with the date it's set for,
+// which is the last one of published rates if rates of the given date aren't published yet.
+func (s *Client) GetRateDate(currency string, t time.Time) (float64, time.Time, error) {
+	var result Result
+	if err := s.currencies(&result, t); err != nil {
+		return 0, time.Time{}, err
+	}
+
+	date, err := time.ParseInLocation(resultDate, result.Date, t.Location())
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	for _, v := range result.Currencies {
+		if v.CharCode == currency {
+			rate, err := currencyRateValue(v)
+			if err != nil {
+				return 0, time.Time{}, err
+			}
+
+			return rate, date, nil
+		}
+	}
+
+	return 0, time.Time{}, fmt.Errorf("unknown currency: %s", currency)
+}
+
 func (s *Client) rate(currency string, t time.Time, hc httpClientInterface) (float64, error) {
 	if Debug {
 		log.Printf("Fetching the currency rate for %s at %v\n", currency, t.Format("02.01.2006"))
@@ -83,7 +119,7 @@ func (s *Client) rate(currency string, t time.Time, hc httpClientInterface) (flo
 
 func (s *Client) currencies(v *Result, t time.Time) error {
 	url := baseURL + "?date_req=" + t.Format(dateFormat)
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(s.ctx, "GET", url, nil)
 	if err != nil {
 		return err
 	}
@@ -1,8 +1,11 @@
 package cbr
 
 import (
+	"context"
 	"errors"
+	"io"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -42,6 +45,37 @@ func (m *mockHttpClientResponseBodyErr) Do(req *http.Request) (*http.Response, e
 	return &http.Response{StatusCode: 200, Body: &mockReadCloser{}}, nil
 }
 
+// mockHttpClientXML is a mock http client with rates of 19.10.2026.
+type mockHttpClientXML struct{}
+
+func (m *mockHttpClientXML) Do(req *http.Request) (*http.Response, error) {
+	body := `<?xml version="1.0" encoding="windows-1251"?>
+<ValCurs Date="19.10.2026" name="Foreign Currency Market">
+<Valute ID="R01235"><NumCode>840</NumCode><CharCode>USD</CharCode><Nominal>1</Nominal><Name>US Dollar</Name><Value>92,4512</Value></Valute>
+<Valute ID="R01375"><NumCode>156</NumCode><CharCode>CNY</CharCode><Nominal>10</Nominal><Name>China Yuan</Name><Value>126,8</Value></Valute>
+</ValCurs>`
+
+	return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body))}, nil
+}
+
+func TestClient_GetRateDate(t *testing.T) {
+	client := NewClient()
+	client.httpClient = &mockHttpClientXML{}
+
+	loc := time.FixedZone("MSK", 3*60*60)
+	rate, date, err := client.GetRateDate("CNY", time.Date(2026, 10, 20, 12, 0, 0, 0, loc))
+	assert.NoError(t, err)
+	assert.Equal(t, 12.68, rate)
+	assert.Equal(t, time.Date(2026, 10, 19, 0, 0, 0, 0, loc), date)
+
+	_, _, err = client.GetRateDate("_", time.Now())
+	assert.EqualError(t, err, "unknown currency: _")
+
+	client.httpClient = &mockHttpClientErr{}
+	_, _, err = client.GetRateDate("USD", time.Now())
+	assert.Error(t, err)
+}
+
 func TestClient_GetRate(t *testing.T) {
 	Debug = true
 
@@ -87,3 +121,12 @@ func Test_currencyRateValue_Error(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, float64(0), rate)
 }
+
+func TestClient_WithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rate, err := NewClient().WithContext(ctx).GetRate("USD", time.Now())
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, float64(0), rate)
+}
@@ -10,6 +10,8 @@ type FetchFunction func(url string) (resp *http.Response, err error)
 // Client is the interface for the rates service.
 type Client interface {
 	GetRate(from, to string) (float64, error)
+	GetRates() (*Rates, error)
+	GetTraderRate(from, to string) (TraderRate, error)
 	SetFetchFunction(FetchFunction)
 }
 
@@ -28,6 +30,28 @@ func (s *client) GetRate(from, to string) (float64, error) {
 	return rate, nil
 }
 
+// GetRates returns merchant and trader rates of every currency pair in one request.
+// See https://developer.coingate.com/docs/list-rates
+func (s *client) GetRates() (*Rates, error) {
+	return getRates(s.fetch)
+}
+
+// GetTraderRate returns the trader buy and sell rates between two currencies.
+// Arguments are ISO Symbol. Example: EUR, USD, BTC, ETH, etc.
+func (s *client) GetTraderRate(from, to string) (TraderRate, error) {
+	buy, err := getTraderRate("buy", from, to, s.fetch)
+	if err != nil {
+		return TraderRate{}, err
+	}
+
+	sell, err := getTraderRate("sell", from, to, s.fetch)
+	if err != nil {
+		return TraderRate{}, err
+	}
+
+	return TraderRate{Buy: buy, Sell: sell}, nil
+}
+
 // SetFetchFunction allows to set a custom fetch function.
 func (s *client) SetFetchFunction(f FetchFunction) {
 	s.fetch = f
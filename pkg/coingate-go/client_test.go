@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -50,6 +51,36 @@ func Test_client_GetRate(t *testing.T) {
 	assert.Equal(t, float64(0), r)
 }
 
+func Test_client_GetTraderRate(t *testing.T) {
+	client := &client{}
+	client.SetFetchFunction(func(url string) (resp *http.Response, err error) {
+		v := "99"
+		if strings.Contains(url, "/buy/") {
+			v = "101"
+		}
+
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(v))),
+		}, nil
+	})
+
+	r, err := client.GetTraderRate("USDT", "RUB")
+	assert.Nil(t, err)
+	assert.Equal(t, TraderRate{Buy: 101, Sell: 99}, r)
+
+	// Error from fetch
+	client.SetFetchFunction(func(url string) (resp *http.Response, err error) {
+		return nil, fmt.Errorf("error")
+	})
+
+	_, err = client.GetTraderRate("USDT", "RUB")
+	assert.Error(t, err)
+
+	_, err = client.GetRates()
+	assert.Error(t, err)
+}
+
 func TestNewClient(t *testing.T) {
 	client := NewClient()
 	assert.NotNil(t, client)
@@ -2,12 +2,13 @@ package coingate
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"strconv"
 )
 
-const baseURL = "https://api.coingate.com/v2/rates/merchant"
+const baseURL = "https://api.coingate.com/v2/rates"
 
 // Debug mode
 // If this variable is set to true, debug mode activated for the package
@@ -27,6 +28,58 @@ type Error struct {
 	Reason  string `json:"reason"`
 }
 
+// Matrix of rates by currency pair, e.g. m["BTC"]["USD"].
+type Matrix map[string]map[string]float64
+
+// Rate returns rate of the pair, if it's in the matrix.
+func (m Matrix) Rate(from, to string) (float64, bool) {
+	v, ok := m[from][to]
+	return v, ok
+}
+
+// Rates of merchant and trader buy and sell matrices.
+type Rates struct {
+	Merchant Matrix
+	Buy      Matrix // Trader buy rates.
+	Sell     Matrix // Trader sell rates.
+}
+
+// TraderRate of a currency pair.
+type TraderRate struct {
+	Buy  float64 // Rate of buying the first currency for the second one, or ask.
+	Sell float64 // Rate of selling the first currency for the second one, or bid.
+}
+
+// Spread between buy and sell rates in percent of the sell rate.
+func (r TraderRate) Spread() float64 {
+	if r.Sell == 0 {
+		return 0
+	}
+
+	return (r.Buy - r.Sell) / r.Sell * 100
+}
+
+// matrix of the response, which rates are strings.
+type matrix map[string]map[string]json.Number
+
+// parse the matrix to floats.
+func (m matrix) parse() (Matrix, error) {
+	res := Matrix{}
+	for from, v := range m {
+		res[from] = map[string]float64{}
+		for to, n := range v {
+			f, err := n.Float64()
+			if err != nil {
+				return nil, fmt.Errorf("%s/%s: %v", from, to, err)
+			}
+
+			res[from][to] = f
+		}
+	}
+
+	return res, nil
+}
+
 // Current exchange rate for any two currencies, fiat or crypto.
 // This endpoint is public, authentication is not required.
 // Arguments are ISO Symbol. Example: EUR, USD, BTC, ETH, etc.
@@ -36,33 +89,102 @@ func getRate(from, to string, fetch FetchFunction) (float64, error) {
 		log.Printf("Fetching the currency rate for %s\n", to)
 	}
 
-	var res float64 = 0
-	url := fmt.Sprintf("%s/%s/%s", baseURL, from, to)
+	return getFloat(fmt.Sprintf("%s/merchant/%s/%s", baseURL, from, to), fetch)
+}
+
+// Current trader buy or sell rate for any two currencies, side is buy or sell.
+// See https://developer.coingate.com/docs/get-rate
+func getTraderRate(side, from, to string, fetch FetchFunction) (float64, error) {
+	if Debug {
+		log.Printf("Fetching the trader %s rate of %s/%s\n", side, from, to)
+	}
+
+	return getFloat(fmt.Sprintf("%s/trader/%s/%s/%s", baseURL, side, from, to), fetch)
+}
+
+// All merchant and trader rates of every currency pair in one call.
+// Response example:
+//
+//	{
+//	  "merchant": {"BTC": {"USD": "62000.0", "RUB": "5800000.0"}},
+//	  "trader": {
+//	    "buy": {"BTC": {"USD": "62500.0"}},
+//	    "sell": {"BTC": {"USD": "61500.0"}}
+//	  }
+//	}
+//
+// See https://developer.coingate.com/docs/list-rates
+func getRates(fetch FetchFunction) (*Rates, error) {
+	if Debug {
+		log.Println("Fetching all currency rates")
+	}
+
+	b, err := get(baseURL, fetch)
+	if err != nil {
+		return nil, err
+	}
+
+	var v struct {
+		Merchant matrix `json:"merchant"`
+		Trader   struct {
+			Buy  matrix `json:"buy"`
+			Sell matrix `json:"sell"`
+		} `json:"trader"`
+	}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+
+	if len(v.Merchant) == 0 {
+		return nil, errors.New("no merchant rates")
+	}
+
+	r := &Rates{}
+	for _, m := range []struct {
+		src matrix
+		dst *Matrix
+	}{{v.Merchant, &r.Merchant}, {v.Trader.Buy, &r.Buy}, {v.Trader.Sell, &r.Sell}} {
+		if *m.dst, err = m.src.parse(); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// getFloat fetches url and parses response as float.
+func getFloat(url string, fetch FetchFunction) (float64, error) {
+	b, err := get(url, fetch)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseFloat(string(b), 64)
+}
+
+// get fetches url and returns response body, or service error.
+func get(url string, fetch FetchFunction) ([]byte, error) {
 	resp, err := fetch(url)
 	if err != nil {
-		return res, err
+		return nil, err
 	}
 
 	defer resp.Body.Close()
 	b, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return res, err
+		return nil, err
 	}
 
 	if resp.StatusCode != 200 {
 		var error Error
 		err = json.Unmarshal(b, &error)
 		if err != nil {
-			return res, err
+			return nil, err
 		}
 
-		return res, fmt.Errorf("service error (message: %s, reason: %s)", error.Message, error.Reason)
+		return nil, fmt.Errorf("service error (message: %s, reason: %s)", error.Message, error.Reason)
 	}
 
-	s := string(b)
-	if res, err = strconv.ParseFloat(s, 64); err != nil {
-		return res, err
-	}
-
-	return res, nil
+	return b, nil
 }
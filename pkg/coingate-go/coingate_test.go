@@ -81,3 +81,88 @@ func Test_getRate(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, float64(0), r)
 }
+
+func Test_getRates(t *testing.T) {
+	var got string
+	fetchFunc := func(url string) (resp *http.Response, err error) {
+		got = url
+		return &http.Response{
+			StatusCode: 200,
+			Body: io.NopCloser(bytes.NewReader([]byte(`{
+				"merchant": {"BTC": {"USD": "62000.5", "RUB": "5800000"}, "USDT": {"RUB": 92.1}},
+				"trader": {"buy": {"BTC": {"USD": "62500"}}, "sell": {"BTC": {"USD": "61500"}}}
+			}`))),
+		}, nil
+	}
+
+	r, err := getRates(fetchFunc)
+	assert.Nil(t, err)
+	assert.Equal(t, baseURL, got)
+
+	v, ok := r.Merchant.Rate("BTC", "USD")
+	assert.True(t, ok)
+	assert.Equal(t, 62000.5, v)
+
+	v, _ = r.Merchant.Rate("USDT", "RUB")
+	assert.Equal(t, 92.1, v)
+
+	_, ok = r.Merchant.Rate("ETH", "USD")
+	assert.False(t, ok)
+
+	assert.Equal(t, Matrix{"BTC": {"USD": 62500}}, r.Buy)
+	assert.Equal(t, Matrix{"BTC": {"USD": 61500}}, r.Sell)
+
+	// Not a number
+	fetchFunc = func(url string) (resp *http.Response, err error) {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"merchant": {"BTC": {"USD": "n/a"}}}`))),
+		}, nil
+	}
+
+	_, err = getRates(fetchFunc)
+	assert.Error(t, err)
+
+	// No rates
+	fetchFunc = func(url string) (resp *http.Response, err error) {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{}`))),
+		}, nil
+	}
+
+	_, err = getRates(fetchFunc)
+	assert.Error(t, err)
+
+	// Error from server
+	fetchFunc = func(url string) (resp *http.Response, err error) {
+		return &http.Response{
+			StatusCode: 503,
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"message":"Service Unavailable","reason":"ServiceUnavailable"}`))),
+		}, nil
+	}
+
+	_, err = getRates(fetchFunc)
+	assert.ErrorContains(t, err, "ServiceUnavailable")
+}
+
+func Test_getTraderRate(t *testing.T) {
+	var got string
+	fetchFunc := func(url string) (resp *http.Response, err error) {
+		got = url
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte("62500"))),
+		}, nil
+	}
+
+	r, err := getTraderRate("buy", "BTC", "USD", fetchFunc)
+	assert.Nil(t, err)
+	assert.Equal(t, float64(62500), r)
+	assert.Equal(t, baseURL+"/trader/buy/BTC/USD", got)
+}
+
+func TestTraderRate_Spread(t *testing.T) {
+	assert.Equal(t, 2.0, TraderRate{Buy: 102, Sell: 100}.Spread())
+	assert.Equal(t, 0.0, TraderRate{}.Spread())
+}
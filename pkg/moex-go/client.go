@@ -2,6 +2,7 @@ package moex
 
 import (
 	"net/http"
+	"time"
 )
 
 // fetchFunction is a function that mimics http.Get() method
@@ -10,6 +11,12 @@ type fetchFunction func(url string) (resp *http.Response, err error)
 // Client is the interface for the rates service.
 type Client interface {
 	GetRate(code string) (float64, error)
+	GetWAPrice(code string) (float64, error)
+	GetFixing(code string) (Fixing, error)
+	GetFixings(code string, from, till time.Time) ([]Fixing, error)
+	GetIndicativeRate(from, to string) (Fixing, error)
+	GetFutures(asset string) ([]Future, error)
+	GetFrontFuture(asset string) (Future, error)
 	SetFetchFunction(fetchFunction)
 }
 
@@ -27,6 +34,36 @@ func (s *client) GetRate(code string) (float64, error) {
 	return rate, nil
 }
 
+// GetWAPrice returns the weighted average price of the day for the given currency code.
+func (s *client) GetWAPrice(code string) (float64, error) {
+	return getWAPrice(code, s.fetch)
+}
+
+// GetFixing returns the latest fixing of the given fixing code, e.g. USDFIXME.
+func (s *client) GetFixing(code string) (Fixing, error) {
+	return getFixing(code, s.fetch)
+}
+
+// GetFixings returns fixings of the given fixing code from one date till another one, the latest last.
+func (s *client) GetFixings(code string, from, till time.Time) ([]Fixing, error) {
+	return getFixings(code, from, till, s.fetch)
+}
+
+// GetIndicativeRate returns the latest indicative rate of the given currency pair, e.g. USD/RUB.
+func (s *client) GetIndicativeRate(from, to string) (Fixing, error) {
+	return getIndicativeRate(from, to, s.fetch)
+}
+
+// GetFutures returns contracts of the given futures asset, e.g. Si, which aren't expired yet, the nearest expiry first.
+func (s *client) GetFutures(asset string) ([]Future, error) {
+	return getFutures(asset, time.Now(), s.fetch)
+}
+
+// GetFrontFuture returns the front contract of the given futures asset, e.g. Si.
+func (s *client) GetFrontFuture(asset string) (Future, error) {
+	return getFrontFuture(asset, time.Now(), s.fetch)
+}
+
 // SetFetchFunction allows to set a custom fetch function.
 func (s *client) SetFetchFunction(f fetchFunction) {
 	s.fetch = f
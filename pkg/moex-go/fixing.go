@@ -0,0 +1,143 @@
+package moex
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Fixing codes
+const (
+	USDFIX = "USDFIXME"
+	EURFIX = "EURFIXME"
+	CNYFIX = "CNYFIX"
+)
+
+// Moscow is the time zone of fixings.
+var Moscow = time.FixedZone("MSK", 3*60*60)
+
+// Fixing is a currency fixing or an indicative rate at time.
+type Fixing struct {
+	Code  string    `json:"code"`
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// String returns the string representation of the fixing.
+func (f Fixing) String() string {
+	return fmt.Sprintf("%s %.4f at %s", f.Code, f.Value, f.Time.Format("02.01.2006 15:04"))
+}
+
+// fixingRow of a statistics block.
+type fixingRow struct {
+	Tradedate string  `json:"tradedate"`
+	Tradetime string  `json:"tradetime"`
+	Secid     string  `json:"secid"`
+	Rate      float64 `json:"rate"`
+}
+
+// Fixings of code, e.g. USDFIXME, from one date till another one, the latest last.
+// This endpoint is public, authentication is not required.
+// See https://iss.moex.com/iss/reference/
+func getFixings(code string, from, till time.Time, fetch fetchFunction) ([]Fixing, error) {
+	if Debug {
+		log.Printf("Fetching the fixings of %s from %s till %s\n", code, from.Format("2006-01-02"), till.Format("2006-01-02"))
+	}
+
+	url := fmt.Sprintf("%s/statistics/engines/currency/markets/fixing/%s.json?from=%s&till=%s&iss.meta=off&iss.json=extended",
+		baseURL, code, from.Format("2006-01-02"), till.Format("2006-01-02"))
+
+	return getStatistics(url, "history", fetch)
+}
+
+// Latest fixing of code, e.g. USDFIXME, of the last week.
+func getFixing(code string, fetch fetchFunction) (Fixing, error) {
+	now := time.Now().In(Moscow)
+
+	ff, err := getFixings(code, now.AddDate(0, 0, -7), now, fetch)
+	if err != nil {
+		return Fixing{}, err
+	}
+
+	if len(ff) == 0 {
+		return Fixing{}, fmt.Errorf("error: no fixings of %s", code)
+	}
+
+	return ff[len(ff)-1], nil
+}
+
+// Latest indicative rate of currency pair, e.g. USD/RUB, derived from the OTC market.
+// See https://iss.moex.com/iss/reference/
+func getIndicativeRate(from, to string, fetch fetchFunction) (Fixing, error) {
+	if Debug {
+		log.Printf("Fetching the indicative rate of %s/%s\n", from, to)
+	}
+
+	url := fmt.Sprintf("%s/statistics/engines/futures/markets/indicativerates/securities/%s/%s.json?iss.meta=off&iss.json=extended",
+		baseURL, from, to)
+
+	ff, err := getStatistics(url, "securities", fetch)
+	if err != nil {
+		return Fixing{}, err
+	}
+
+	if len(ff) == 0 {
+		return Fixing{}, fmt.Errorf("error: no indicative rates of %s/%s", from, to)
+	}
+
+	return ff[len(ff)-1], nil
+}
+
+// getStatistics returns rates of the block of the url response, sorted by time.
+func getStatistics(url, block string, fetch fetchFunction) ([]Fixing, error) {
+	resp, err := fetch(url)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &values); err != nil {
+		return nil, err
+	}
+
+	if len(values) < 2 {
+		return nil, fmt.Errorf("error: length of values less than 2")
+	}
+
+	raw, ok := values[1][block]
+	if !ok {
+		return nil, fmt.Errorf("error: no %s block", block)
+	}
+
+	rows := []fixingRow{}
+	if err := json.Unmarshal(raw, &rows); err != nil {
+		return nil, err
+	}
+
+	ff := []Fixing{}
+	for _, r := range rows {
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", r.Tradedate+" "+r.Tradetime, Moscow)
+		if err != nil {
+			return nil, fmt.Errorf("error: time of %s: %w", r.Secid, err)
+		}
+
+		if r.Rate == 0 {
+			continue
+		}
+
+		ff = append(ff, Fixing{Code: r.Secid, Time: t, Value: r.Rate})
+	}
+
+	sort.SliceStable(ff, func(i, j int) bool { return ff[i].Time.Before(ff[j].Time) })
+
+	return ff, nil
+}
@@ -0,0 +1,97 @@
+package moex
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const fixings = `[{"charsetinfo": {"name": "utf-8"}}, {"history": [
+	{"tradedate": "2024-06-13", "tradetime": "15:30:00", "secid": "USDFIXME", "rate": 88.7},
+	{"tradedate": "2024-06-13", "tradetime": "12:30:00", "secid": "USDFIXME", "rate": 89.1},
+	{"tradedate": "2024-06-14", "tradetime": "12:30:00", "secid": "USDFIXME", "rate": 0}]}]`
+
+func response(body string) fetchFunction {
+	return func(url string) (resp *http.Response, err error) {
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(bytes.NewReader([]byte(body)))}, nil
+	}
+}
+
+func Test_getFixings(t *testing.T) {
+	Debug = true
+
+	from := time.Date(2024, 6, 10, 0, 0, 0, 0, Moscow)
+	till := time.Date(2024, 6, 14, 0, 0, 0, 0, Moscow)
+
+	url := ""
+	ff, err := getFixings(USDFIX, from, till, func(u string) (*http.Response, error) {
+		url = u
+		return response(fixings)(u)
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(strings.Split(url, "?")[0], "/statistics/engines/currency/markets/fixing/USDFIXME.json"))
+	assert.Contains(t, url, "from=2024-06-10&till=2024-06-14")
+	assert.Equal(t, []Fixing{
+		{Code: USDFIX, Time: time.Date(2024, 6, 13, 12, 30, 0, 0, Moscow), Value: 89.1},
+		{Code: USDFIX, Time: time.Date(2024, 6, 13, 15, 30, 0, 0, Moscow), Value: 88.7},
+	}, ff)
+
+	// Errors
+	_, err = getFixings(USDFIX, from, till, func(url string) (*http.Response, error) { return nil, fmt.Errorf("error") })
+	assert.Error(t, err)
+
+	for _, body := range []string{`{}`, `[{}]`, `[{}, {"securities": []}]`, `[{}, {"history": [{"tradedate": "13.06.2024"}]}]`} {
+		_, err = getFixings(USDFIX, from, till, response(body))
+		assert.Error(t, err, body)
+	}
+}
+
+func Test_getFixing(t *testing.T) {
+	f, err := getFixing(USDFIX, response(fixings))
+	assert.NoError(t, err)
+	assert.Equal(t, 88.7, f.Value)
+	assert.Equal(t, "USDFIXME 88.7000 at 13.06.2024 15:30", f.String())
+
+	_, err = getFixing(USDFIX, response(`[{}, {"history": []}]`))
+	assert.Error(t, err)
+}
+
+func Test_getIndicativeRate(t *testing.T) {
+	url := ""
+	f, err := getIndicativeRate("USD", "RUB", func(u string) (*http.Response, error) {
+		url = u
+		return response(`[{}, {"securities": [
+			{"tradedate": "2024-06-13", "tradetime": "13:45:00", "secid": "USD/RUB", "rate": 89.05, "clearing": "pk"},
+			{"tradedate": "2024-06-13", "tradetime": "18:45:00", "secid": "USD/RUB", "rate": 88.95, "clearing": "vk"}]}]`)(u)
+	})
+
+	assert.NoError(t, err)
+	assert.Contains(t, url, "/statistics/engines/futures/markets/indicativerates/securities/USD/RUB.json")
+	assert.Equal(t, Fixing{Code: "USD/RUB", Time: time.Date(2024, 6, 13, 18, 45, 0, 0, Moscow), Value: 88.95}, f)
+
+	_, err = getIndicativeRate("USD", "RUB", response(`[{}, {"securities": []}]`))
+	assert.Error(t, err)
+}
+
+func Test_client_GetFixing(t *testing.T) {
+	c := &client{}
+	c.SetFetchFunction(response(fixings))
+
+	f, err := c.GetFixing(USDFIX)
+	assert.NoError(t, err)
+	assert.Equal(t, 88.7, f.Value)
+
+	ff, err := c.GetFixings(USDFIX, time.Now(), time.Now())
+	assert.NoError(t, err)
+	assert.Len(t, ff, 2)
+
+	_, err = c.GetIndicativeRate("USD", "RUB")
+	assert.Error(t, err)
+}
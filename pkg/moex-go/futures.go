@@ -0,0 +1,150 @@
+package moex
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Futures assets
+const (
+	Si = "Si" // USD/RUB
+	CR = "CR" // CNY/RUB
+)
+
+// futuresAssets by code with their currency pairs and units of price, e.g. Si is quoted in RUB per 1000 USD.
+var futuresAssets = map[string]struct {
+	pair string
+	unit float64
+}{
+	Si: {"USD/RUB", 1000},
+	CR: {"CNY/RUB", 1},
+}
+
+// FuturesPair returns currency pair of futures asset, e.g. USD/RUB of Si, or empty one if it's unsupported.
+func FuturesPair(asset string) string {
+	return futuresAssets[asset].pair
+}
+
+// Future is a futures contract on FORTS.
+type Future struct {
+	Code   string    `json:"code"`   // Code of the contract, e.g. SiZ4.
+	Name   string    `json:"name"`   // Short name of the contract, e.g. Si-12.24.
+	Asset  string    `json:"asset"`  // Code of the asset, e.g. Si.
+	Expiry time.Time `json:"expiry"` // Last trading date.
+	Price  float64   `json:"price"`  // Last price, or previous settlement price if there are no trades, per 1 of currency.
+}
+
+// Days to expiry of the contract since t.
+func (f Future) Days(t time.Time) int {
+	y, m, d := t.In(Moscow).Date()
+	return int(f.Expiry.Sub(time.Date(y, m, d, 0, 0, 0, 0, Moscow)).Hours() / 24)
+}
+
+// futures response of the FORTS market.
+type futures struct {
+	Securities []struct {
+		Secid           string  `json:"SECID"`
+		Shortname       string  `json:"SHORTNAME"`
+		Assetcode       string  `json:"ASSETCODE"`
+		Lasttradedate   string  `json:"LASTTRADEDATE"`
+		Prevsettleprice float64 `json:"PREVSETTLEPRICE"`
+	} `json:"securities"`
+
+	Marketdata []struct {
+		Secid string  `json:"SECID"`
+		Last  float64 `json:"LAST"`
+	} `json:"marketdata"`
+}
+
+// Futures contracts of asset, e.g. Si, which aren't expired yet, the nearest expiry first.
+// This endpoint is public, authentication is not required.
+// See https://iss.moex.com/iss/reference/
+func getFutures(asset string, now time.Time, fetch fetchFunction) ([]Future, error) {
+	a, ok := futuresAssets[asset]
+	if !ok {
+		return nil, fmt.Errorf("error: unsupported futures asset %s", asset)
+	}
+
+	if Debug {
+		log.Printf("Fetching the futures of %s\n", asset)
+	}
+
+	url := fmt.Sprintf("%s/engines/futures/markets/forts/securities.json?assets=%s&iss.only=securities,marketdata&iss.meta=off&iss.json=extended",
+		baseURL, asset)
+
+	resp, err := fetch(url)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []futures{}
+	if err := json.Unmarshal(body, &values); err != nil {
+		return nil, err
+	}
+
+	if len(values) < 2 {
+		return nil, fmt.Errorf("error: length of values less than 2")
+	}
+
+	last := map[string]float64{}
+	for _, md := range values[1].Marketdata {
+		last[md.Secid] = md.Last
+	}
+
+	y, m, d := now.In(Moscow).Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, Moscow)
+
+	ff := []Future{}
+	for _, s := range values[1].Securities {
+		// Perpetual futures have their own assets, e.g. USDRUBF
+		if s.Assetcode != asset {
+			continue
+		}
+
+		expiry, err := time.ParseInLocation("2006-01-02", s.Lasttradedate, Moscow)
+		if err != nil {
+			return nil, fmt.Errorf("error: expiry of %s: %w", s.Secid, err)
+		}
+
+		if expiry.Before(today) {
+			continue
+		}
+
+		price := last[s.Secid]
+		if price == 0 {
+			price = s.Prevsettleprice
+		}
+
+		ff = append(ff, Future{Code: s.Secid, Name: s.Shortname, Asset: asset, Expiry: expiry, Price: price / a.unit})
+	}
+
+	sort.SliceStable(ff, func(i, j int) bool { return ff[i].Expiry.Before(ff[j].Expiry) })
+
+	return ff, nil
+}
+
+// Front contract of asset, e.g. Si, which is the nearest one to expire with a price.
+func getFrontFuture(asset string, now time.Time, fetch fetchFunction) (Future, error) {
+	ff, err := getFutures(asset, now, fetch)
+	if err != nil {
+		return Future{}, err
+	}
+
+	for _, f := range ff {
+		if f.Price > 0 {
+			return f, nil
+		}
+	}
+
+	return Future{}, fmt.Errorf("error: no futures of %s", asset)
+}
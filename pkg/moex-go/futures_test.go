@@ -0,0 +1,87 @@
+package moex
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const forts = `[{"charsetinfo": {"name": "utf-8"}}, {
+	"securities": [
+		{"SECID": "SiH5", "SHORTNAME": "Si-3.25", "ASSETCODE": "Si", "LASTTRADEDATE": "2025-03-20", "PREVSETTLEPRICE": 95100},
+		{"SECID": "SiU4", "SHORTNAME": "Si-9.24", "ASSETCODE": "Si", "LASTTRADEDATE": "2024-09-19", "PREVSETTLEPRICE": 88000},
+		{"SECID": "SiZ4", "SHORTNAME": "Si-12.24", "ASSETCODE": "Si", "LASTTRADEDATE": "2024-12-19", "PREVSETTLEPRICE": 92400},
+		{"SECID": "USDRUBF", "SHORTNAME": "USDRUBF", "ASSETCODE": "USDRUBF", "LASTTRADEDATE": "2030-01-01", "PREVSETTLEPRICE": 90.1}],
+	"marketdata": [
+		{"SECID": "SiH5", "LAST": 0},
+		{"SECID": "SiZ4", "LAST": 92500},
+		{"SECID": "USDRUBF", "LAST": 90.2}]}]`
+
+func Test_getFutures(t *testing.T) {
+	now := time.Date(2024, 10, 24, 15, 0, 0, 0, Moscow)
+
+	url := ""
+	ff, err := getFutures(Si, now, func(u string) (*http.Response, error) {
+		url = u
+		return response(forts)(u)
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(strings.Split(url, "?")[0], "/engines/futures/markets/forts/securities.json"))
+	assert.Contains(t, url, "assets=Si")
+	assert.Equal(t, []Future{
+		{Code: "SiZ4", Name: "Si-12.24", Asset: Si, Expiry: time.Date(2024, 12, 19, 0, 0, 0, 0, Moscow), Price: 92.5},
+		{Code: "SiH5", Name: "Si-3.25", Asset: Si, Expiry: time.Date(2025, 3, 20, 0, 0, 0, 0, Moscow), Price: 95.1},
+	}, ff)
+	assert.Equal(t, 56, ff[0].Days(now))
+
+	// Errors
+	_, err = getFutures("Eu", now, response(forts))
+	assert.Error(t, err)
+
+	_, err = getFutures(Si, now, func(url string) (*http.Response, error) { return nil, fmt.Errorf("error") })
+	assert.Error(t, err)
+
+	for _, body := range []string{`{}`, `[{}]`, `[{}, {"securities": [{"SECID": "SiZ4", "ASSETCODE": "Si", "LASTTRADEDATE": "19.12.2024"}]}]`} {
+		_, err = getFutures(Si, now, response(body))
+		assert.Error(t, err, body)
+	}
+}
+
+func Test_getFrontFuture(t *testing.T) {
+	f, err := getFrontFuture(Si, time.Date(2024, 10, 24, 0, 0, 0, 0, Moscow), response(forts))
+	assert.NoError(t, err)
+	assert.Equal(t, "SiZ4", f.Code)
+
+	// Expiry day is still front
+	f, _ = getFrontFuture(Si, time.Date(2024, 12, 19, 18, 0, 0, 0, Moscow), response(forts))
+	assert.Equal(t, "SiZ4", f.Code)
+	assert.Equal(t, 0, f.Days(time.Date(2024, 12, 19, 18, 0, 0, 0, Moscow)))
+
+	f, _ = getFrontFuture(Si, time.Date(2024, 12, 20, 0, 0, 0, 0, Moscow), response(forts))
+	assert.Equal(t, "SiH5", f.Code)
+
+	_, err = getFrontFuture(Si, time.Date(2025, 4, 1, 0, 0, 0, 0, Moscow), response(forts))
+	assert.Error(t, err)
+}
+
+func TestFuturesPair(t *testing.T) {
+	assert.Equal(t, "CNY/RUB", FuturesPair(CR))
+	assert.Empty(t, FuturesPair("Eu"))
+}
+
+func Test_client_GetFutures(t *testing.T) {
+	c := &client{}
+	c.SetFetchFunction(response(`[{}, {"securities": [], "marketdata": []}]`))
+
+	ff, err := c.GetFutures(CR)
+	assert.NoError(t, err)
+	assert.Empty(t, ff)
+
+	_, err = c.GetFrontFuture(CR)
+	assert.Error(t, err)
+}
@@ -53,53 +53,56 @@ type currency struct {
 		Lotdivider  int         `json:"LOTDIVIDER"`
 	} `json:"securities,omitempty"`
 
-	Marketdata []struct {
-		Highbid               interface{} `json:"HIGHBID"`
-		Biddepth              interface{} `json:"BIDDEPTH"`
-		Lowoffer              interface{} `json:"LOWOFFER"`
-		Offerdepth            interface{} `json:"OFFERDEPTH"`
-		Spread                float64     `json:"SPREAD"`
-		High                  float64     `json:"HIGH"`
-		Low                   float64     `json:"LOW"`
-		Open                  float64     `json:"OPEN"`
-		Last                  float64     `json:"LAST"`
-		Lastcngtolastwaprice  float64     `json:"LASTCNGTOLASTWAPRICE"`
-		Valtoday              float64     `json:"VALTODAY"`
-		Voltoday              float64     `json:"VOLTODAY"`
-		ValtodayUsd           float64     `json:"VALTODAY_USD"`
-		Waprice               float64     `json:"WAPRICE"`
-		Waptoprevwaprice      float64     `json:"WAPTOPREVWAPRICE"`
-		Closeprice            interface{} `json:"CLOSEPRICE"`
-		Numtrades             int         `json:"NUMTRADES"`
-		Tradingstatus         string      `json:"TRADINGSTATUS"`
-		Updatetime            string      `json:"UPDATETIME"`
-		Boardid               string      `json:"BOARDID"`
-		Secid                 string      `json:"SECID"`
-		Waptoprevwapriceprcnt float64     `json:"WAPTOPREVWAPRICEPRCNT"`
-		Bid                   interface{} `json:"BID"`
-		Biddeptht             interface{} `json:"BIDDEPTHT"`
-		Numbids               interface{} `json:"NUMBIDS"`
-		Offer                 interface{} `json:"OFFER"`
-		Offerdeptht           interface{} `json:"OFFERDEPTHT"`
-		Numoffers             interface{} `json:"NUMOFFERS"`
-		Change                float64     `json:"CHANGE"`
-		Lastchangeprcnt       float64     `json:"LASTCHANGEPRCNT"`
-		Value                 float64     `json:"VALUE"`
-		ValueUsd              float64     `json:"VALUE_USD"`
-		Seqnum                int64       `json:"SEQNUM"`
-		Qty                   int         `json:"QTY"`
-		Time                  string      `json:"TIME"`
-		Priceminusprevwaprice float64     `json:"PRICEMINUSPREVWAPRICE"`
-		Lastchange            float64     `json:"LASTCHANGE"`
-		Lasttoprevprice       float64     `json:"LASTTOPREVPRICE"`
-		ValtodayRur           int64       `json:"VALTODAY_RUR"`
-		Systime               string      `json:"SYSTIME"`
-		Marketprice           float64     `json:"MARKETPRICE"`
-		Marketpricetoday      float64     `json:"MARKETPRICETODAY"`
-		Marketprice2          interface{} `json:"MARKETPRICE2"`
-		Admittedquote         interface{} `json:"ADMITTEDQUOTE"`
-		Lopenprice            float64     `json:"LOPENPRICE"`
-	} `json:"marketdata,omitempty"`
+	Marketdata []marketdata `json:"marketdata,omitempty"`
+}
+
+// marketdata of a security.
+type marketdata struct {
+	Highbid               interface{} `json:"HIGHBID"`
+	Biddepth              interface{} `json:"BIDDEPTH"`
+	Lowoffer              interface{} `json:"LOWOFFER"`
+	Offerdepth            interface{} `json:"OFFERDEPTH"`
+	Spread                float64     `json:"SPREAD"`
+	High                  float64     `json:"HIGH"`
+	Low                   float64     `json:"LOW"`
+	Open                  float64     `json:"OPEN"`
+	Last                  float64     `json:"LAST"`
+	Lastcngtolastwaprice  float64     `json:"LASTCNGTOLASTWAPRICE"`
+	Valtoday              float64     `json:"VALTODAY"`
+	Voltoday              float64     `json:"VOLTODAY"`
+	ValtodayUsd           float64     `json:"VALTODAY_USD"`
+	Waprice               float64     `json:"WAPRICE"`
+	Waptoprevwaprice      float64     `json:"WAPTOPREVWAPRICE"`
+	Closeprice            interface{} `json:"CLOSEPRICE"`
+	Numtrades             int         `json:"NUMTRADES"`
+	Tradingstatus         string      `json:"TRADINGSTATUS"`
+	Updatetime            string      `json:"UPDATETIME"`
+	Boardid               string      `json:"BOARDID"`
+	Secid                 string      `json:"SECID"`
+	Waptoprevwapriceprcnt float64     `json:"WAPTOPREVWAPRICEPRCNT"`
+	Bid                   interface{} `json:"BID"`
+	Biddeptht             interface{} `json:"BIDDEPTHT"`
+	Numbids               interface{} `json:"NUMBIDS"`
+	Offer                 interface{} `json:"OFFER"`
+	Offerdeptht           interface{} `json:"OFFERDEPTHT"`
+	Numoffers             interface{} `json:"NUMOFFERS"`
+	Change                float64     `json:"CHANGE"`
+	Lastchangeprcnt       float64     `json:"LASTCHANGEPRCNT"`
+	Value                 float64     `json:"VALUE"`
+	ValueUsd              float64     `json:"VALUE_USD"`
+	Seqnum                int64       `json:"SEQNUM"`
+	Qty                   int         `json:"QTY"`
+	Time                  string      `json:"TIME"`
+	Priceminusprevwaprice float64     `json:"PRICEMINUSPREVWAPRICE"`
+	Lastchange            float64     `json:"LASTCHANGE"`
+	Lasttoprevprice       float64     `json:"LASTTOPREVPRICE"`
+	ValtodayRur           int64       `json:"VALTODAY_RUR"`
+	Systime               string      `json:"SYSTIME"`
+	Marketprice           float64     `json:"MARKETPRICE"`
+	Marketpricetoday      float64     `json:"MARKETPRICETODAY"`
+	Marketprice2          interface{} `json:"MARKETPRICE2"`
+	Admittedquote         interface{} `json:"ADMITTEDQUOTE"`
+	Lopenprice            float64     `json:"LOPENPRICE"`
 }
 
 // String returns the string representation of the currency.
@@ -125,7 +128,36 @@ func getRate(code string, fetch fetchFunction) (float64, error) {
 		log.Printf("Fetching the currency rate for %s\n", code)
 	}
 
-	var res float64 = 0
+	md, err := getMarketdata(code, fetch)
+	if err != nil {
+		return 0, err
+	}
+
+	return md.Last, nil
+}
+
+// Weighted average price of the day for two currencies.
+// Example: CNYRUB_TOM.
+func getWAPrice(code string, fetch fetchFunction) (float64, error) {
+	if Debug {
+		log.Printf("Fetching the weighted average price for %s\n", code)
+	}
+
+	md, err := getMarketdata(code, fetch)
+	if err != nil {
+		return 0, err
+	}
+
+	if md.Waprice == 0 {
+		return 0, fmt.Errorf("error: no weighted average price of %s", code)
+	}
+
+	return md.Waprice, nil
+}
+
+// getMarketdata of security code.
+func getMarketdata(code string, fetch fetchFunction) (marketdata, error) {
+	var res marketdata
 	url := fmt.Sprintf("%s%s%s", baseURL,
 		"/engines/currency/markets/selt/securities.json?iss.only=securities,marketdata&lang=en&iss.meta=off&iss.json=extended",
 		"&securities=CETS:"+code)
@@ -163,9 +195,8 @@ func getRate(code string, fetch fetchFunction) (float64, error) {
 
 	md := val.Marketdata
 	if len(md) == 0 {
-		return 0, fmt.Errorf("error: length of md equals 0")
+		return res, fmt.Errorf("error: length of md equals 0")
 	}
 
-	res = md[0].Last
-	return res, nil
+	return md[0], nil
 }
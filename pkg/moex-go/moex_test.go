@@ -94,6 +94,41 @@ func Test_getRate(t *testing.T) {
 	assert.Equal(t, float64(0), r)
 }
 
+func Test_getWAPrice(t *testing.T) {
+	fetchFunc := func(url string) (resp *http.Response, err error) {
+		return &http.Response{
+			StatusCode: 200,
+			Body: io.NopCloser(bytes.NewReader(
+				[]byte(`[{"charsetinfo": {}}, {"charsetinfo": {}, "securities": [], "marketdata": [{"LAST": 12.7, "WAPRICE": 12.685}]}]`))),
+		}, nil
+	}
+
+	r, err := getWAPrice("C1", fetchFunc)
+	assert.NoError(t, err)
+	assert.Equal(t, 12.685, r)
+
+	c := &client{}
+	c.SetFetchFunction(fetchFunc)
+	r, err = c.GetWAPrice("C1")
+	assert.NoError(t, err)
+	assert.Equal(t, 12.685, r)
+
+	// No trades yet
+	fetchFunc = func(url string) (resp *http.Response, err error) {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader([]byte(`[{"charsetinfo": {}}, {"charsetinfo": {}, "securities": [], "marketdata": [{"LAST": 0}]}]`))),
+		}, nil
+	}
+
+	_, err = getWAPrice("C1", fetchFunc)
+	assert.Error(t, err)
+
+	// Error from fetch
+	_, err = getWAPrice("C1", func(url string) (resp *http.Response, err error) { return nil, fmt.Errorf("error") })
+	assert.Error(t, err)
+}
+
 func TestCurrency_String(t *testing.T) {
 	s := Currency{
 		values: []currency{